@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"test_task/internal/database"
 	"test_task/internal/handler"
+	"test_task/internal/migrator"
 	"test_task/internal/repository"
 	"test_task/internal/service"
+	"test_task/migrations"
+	"time"
+	_ "time/tzdata"
 
 	_ "test_task/docs"
 
@@ -35,6 +43,24 @@ import (
 //
 // @tag.name subscriptions
 // @tag.description Subscription management endpoints
+//
+// @tag.name users
+// @tag.description User management endpoints
+//
+// @tag.name services
+// @tag.description Service catalog endpoints
+//
+// @tag.name categories
+// @tag.description Subscription categories
+//
+// @tag.name budgets
+// @tag.description Spending budgets and alerts
+//
+// @tag.name webhooks
+// @tag.description Outgoing webhooks for subscription events
+//
+// @tag.name reports
+// @tag.description Reports over subscriptions and cancellations
 
 func main() {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
@@ -52,23 +78,185 @@ func main() {
 	}
 	defer database.CloseDB(db)
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), db, os.Args[2:]); err != nil {
+			slog.Error("Ошибка выполнения миграций", "error", err)
+			database.CloseDB(db)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if os.Getenv("MIGRATE_ON_START") == "true" {
+		m, err := migrator.New(db, migrations.FS)
+		if err == nil {
+			err = m.Up(context.Background())
+		}
+		if err != nil {
+			slog.Error("Ошибка применения миграций", "error", err)
+			return
+		}
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "3000"
 	}
 
-	subRepo := repository.NewSubscriptionRepository(db)
-	subService := service.NewSubscriptionService(subRepo)
+	txOpts, err := txOptionsFromEnv()
+	if err != nil {
+		slog.Error("Ошибка настройки транзакций", "error", err)
+		return
+	}
+
+	cluster, err := database.InitCluster(db, replicaConnStrs(os.Getenv("DATABASE_REPLICA_URLS")))
+	if err != nil {
+		slog.Error("Ошибка подключения к репликам БД", "error", err)
+		return
+	}
+	defer cluster.Close()
+
+	checkInterval, err := durationFromEnv("DB_REPLICA_CHECK_INTERVAL", 10*time.Second)
+	if err != nil {
+		slog.Error("Ошибка настройки реплик", "error", err)
+		return
+	}
+	cluster.StartHealthChecks(context.Background(), checkInterval)
+
+	categoryRepo := repository.NewCategoryRepository(cluster)
+	categoryService := service.NewCategoryService(categoryRepo)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+
+	catalogRepo := repository.NewServiceRepository(cluster, txOpts)
+	catalogService := service.NewCatalogService(catalogRepo)
+	catalogHandler := handler.NewCatalogHandler(catalogService)
+
+	overlapPolicy, err := service.ParseOverlapPolicy(os.Getenv("OVERLAP_POLICY"))
+	if err != nil {
+		slog.Error("Ошибка настройки проверки пересечений", "error", err)
+		return
+	}
+
+	subRepo := repository.NewSubscriptionRepository(cluster, txOpts)
+	subService := service.NewSubscriptionService(subRepo, catalogService, overlapPolicy)
 	subHandler := handler.NewSubscriptionHandler(subService)
 
+	userRepo := repository.NewUserRepository(cluster)
+	userService := service.NewUserService(userRepo)
+	userHandler := handler.NewUserHandler(userService, subService)
+
+	channels, defaultChannel, err := notifiersFromEnv()
+	if err != nil {
+		slog.Error("Ошибка настройки уведомлений", "error", err)
+		return
+	}
+
+	notificationRepo := repository.NewNotificationRepository(cluster)
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, channels, defaultChannel)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+
+	budgetRepo := repository.NewBudgetRepository(cluster)
+	budgetService := service.NewBudgetService(budgetRepo, subService, notificationService)
+	budgetHandler := handler.NewBudgetHandler(budgetService)
+
+	statementRepo := repository.NewStatementRepository(cluster)
+	statementService := service.NewStatementService(statementRepo, userRepo, subService, budgetService)
+	statementHandler := handler.NewStatementHandler(statementService)
+
+	webhookMaxAttempts, webhookRetryBase, err := webhookRetriesFromEnv()
+	if err != nil {
+		slog.Error("Ошибка настройки вебхуков", "error", err)
+		return
+	}
+
+	webhookRepo := repository.NewWebhookRepository(cluster, txOpts)
+	webhookService := service.NewWebhookService(webhookRepo, webhookMaxAttempts, webhookRetryBase)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+
+	eventRetention, err := durationFromEnv("EVENT_LOG_RETENTION", 7*24*time.Hour)
+	if err != nil {
+		slog.Error("Ошибка настройки журнала событий", "error", err)
+		return
+	}
+
+	eventRepo := repository.NewEventRepository(cluster, connStr)
+	eventService := service.NewEventService(eventRepo, catalogService, eventRetention)
+	eventHandler := handler.NewEventHandler(eventService)
+
+	if err := eventService.Start(context.Background()); err != nil {
+		slog.Error("Ошибка запуска потока событий", "error", err)
+		return
+	}
+
+	if os.Getenv("SCHEDULER_ENABLED") != "false" {
+		sched, err := newScheduler(db, subRepo, notificationRepo, notificationService, budgetService, statementService, webhookService, eventService)
+		if err != nil {
+			slog.Error("Ошибка настройки планировщика", "error", err)
+			return
+		}
+		sched.Start(context.Background())
+	}
+
 	router := mux.NewRouter()
+	router.Use(handler.ReadYourWrites)
 
 	router.HandleFunc("/subscriptions", subHandler.CreateSubHandler).Methods("POST")
 	router.HandleFunc("/subscriptions", subHandler.GetAllSubsHandler).Methods("GET")
 	router.HandleFunc("/subscriptions/total", subHandler.GetTotalCostHandler).Methods("GET")
+	router.HandleFunc("/subscriptions/events", eventHandler.StreamEventsHandler).Methods("GET")
+	router.HandleFunc("/subscriptions/trials", subHandler.GetTrialConversionsHandler).Methods("GET")
+	router.HandleFunc("/subscriptions/forecast", subHandler.GetForecastHandler).Methods("GET")
+	router.HandleFunc("/subscriptions/search", subHandler.SearchSubsHandler).Methods("GET")
+	router.HandleFunc("/subscriptions/autocomplete", subHandler.AutocompleteServiceNamesHandler).Methods("GET")
 	router.HandleFunc("/subscriptions/{id}", subHandler.GetSubHandler).Methods("GET")
 	router.HandleFunc("/subscriptions/{id}", subHandler.DeleteSubHandler).Methods("DELETE")
 	router.HandleFunc("/subscriptions/{id}", subHandler.UpdateSubHandler).Methods("PUT")
+	router.HandleFunc("/subscriptions/{id}/pause", subHandler.PauseSubHandler).Methods("POST")
+	router.HandleFunc("/subscriptions/{id}/resume", subHandler.ResumeSubHandler).Methods("POST")
+	router.HandleFunc("/subscriptions/{id}/cancel", subHandler.CancelSubHandler).Methods("POST")
+	router.HandleFunc("/users", userHandler.CreateUserHandler).Methods("POST")
+	router.HandleFunc("/users", userHandler.GetAllUsersHandler).Methods("GET")
+	router.HandleFunc("/users/{id}", userHandler.GetUserHandler).Methods("GET")
+	router.HandleFunc("/users/{id}", userHandler.UpdateUserHandler).Methods("PUT")
+	router.HandleFunc("/users/{id}", userHandler.DeleteUserHandler).Methods("DELETE")
+	router.HandleFunc("/users/{id}/subscriptions", userHandler.GetUserSubsHandler).Methods("GET")
+	router.HandleFunc("/users/{id}/total", userHandler.GetUserTotalHandler).Methods("GET")
+	router.HandleFunc("/users/{id}/calendar-token", userHandler.IssueCalendarTokenHandler).Methods("POST")
+	router.HandleFunc("/users/{id}/calendar.ics", userHandler.GetCalendarHandler).Methods("GET")
+	router.HandleFunc("/users/{id}/statements", statementHandler.GenerateStatementHandler).Methods("POST")
+	router.HandleFunc("/users/{id}/statements", statementHandler.GetStatementsHandler).Methods("GET")
+	router.HandleFunc("/users/{id}/statements/{month}", statementHandler.GetStatementHandler).Methods("GET")
+	router.HandleFunc("/users/{id}/notification-preferences", notificationHandler.GetPreferencesHandler).Methods("GET")
+	router.HandleFunc("/users/{id}/notification-preferences", notificationHandler.SetPreferencesHandler).Methods("PUT")
+	router.HandleFunc("/services", catalogHandler.CreateServiceHandler).Methods("POST")
+	router.HandleFunc("/services", catalogHandler.GetAllServicesHandler).Methods("GET")
+	router.HandleFunc("/services/{id}", catalogHandler.GetServiceHandler).Methods("GET")
+	router.HandleFunc("/services/{id}", catalogHandler.UpdateServiceHandler).Methods("PUT")
+	router.HandleFunc("/services/{id}", catalogHandler.DeleteServiceHandler).Methods("DELETE")
+	router.HandleFunc("/categories", categoryHandler.CreateCategoryHandler).Methods("POST")
+	router.HandleFunc("/categories", categoryHandler.GetAllCategoriesHandler).Methods("GET")
+	router.HandleFunc("/categories/{name}", categoryHandler.DeleteCategoryHandler).Methods("DELETE")
+	router.HandleFunc("/budgets", budgetHandler.CreateBudgetHandler).Methods("POST")
+	router.HandleFunc("/budgets", budgetHandler.GetAllBudgetsHandler).Methods("GET")
+	router.HandleFunc("/budgets/{id}", budgetHandler.GetBudgetHandler).Methods("GET")
+	router.HandleFunc("/budgets/{id}", budgetHandler.UpdateBudgetHandler).Methods("PUT")
+	router.HandleFunc("/budgets/{id}", budgetHandler.DeleteBudgetHandler).Methods("DELETE")
+	router.HandleFunc("/budgets/{id}/status", budgetHandler.GetBudgetStatusHandler).Methods("GET")
+	router.HandleFunc("/webhooks", webhookHandler.CreateWebhookHandler).Methods("POST")
+	router.HandleFunc("/webhooks", webhookHandler.GetAllWebhooksHandler).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", webhookHandler.GetWebhookHandler).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", webhookHandler.UpdateWebhookHandler).Methods("PUT")
+	router.HandleFunc("/webhooks/{id}", webhookHandler.DeleteWebhookHandler).Methods("DELETE")
+	router.HandleFunc("/webhooks/{id}/deliveries", webhookHandler.GetDeliveriesHandler).Methods("GET")
+	router.HandleFunc("/reports/churn", subHandler.GetChurnReportHandler).Methods("GET")
+	router.HandleFunc("/reports/settlement", subHandler.GetSettlementHandler).Methods("GET")
+	router.HandleFunc("/reports/overlaps", subHandler.GetOverlapsHandler).Methods("GET")
+	router.HandleFunc("/reports/anomalies", subHandler.GetAnomaliesHandler).Methods("GET")
+	router.HandleFunc("/reports/top-services", subHandler.GetTopServicesHandler).Methods("GET")
+	router.HandleFunc("/reports/top-users", subHandler.GetTopUsersHandler).Methods("GET")
+	router.HandleFunc("/reports/common-services", subHandler.GetCommonServicesHandler).Methods("GET")
+	router.HandleFunc("/reports/average-prices", subHandler.GetAveragePricesHandler).Methods("GET")
+	router.HandleFunc("/reports/retention", subHandler.GetRetentionHandler).Methods("GET")
 	router.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
 		httpSwagger.URL("./swagger/doc.json"),
 		httpSwagger.DeepLinking(true),
@@ -88,3 +276,34 @@ func main() {
 		return
 	}
 }
+
+// replicaConnStrs splits a comma-separated list of replica connection strings.
+func replicaConnStrs(list string) []string {
+	var connStrs []string
+	for _, connStr := range strings.Split(list, ",") {
+		if connStr = strings.TrimSpace(connStr); connStr != "" {
+			connStrs = append(connStrs, connStr)
+		}
+	}
+
+	return connStrs
+}
+
+func txOptionsFromEnv() (repository.TxOptions, error) {
+	opts := repository.DefaultTxOptions()
+
+	isolation, err := repository.ParseIsolationLevel(os.Getenv("DB_TX_ISOLATION"))
+	if err != nil {
+		return opts, err
+	}
+	opts.Isolation = isolation
+
+	if retries := os.Getenv("DB_TX_MAX_RETRIES"); retries != "" {
+		opts.MaxRetries, err = strconv.Atoi(retries)
+		if err != nil || opts.MaxRetries < 0 {
+			return opts, fmt.Errorf("invalid DB_TX_MAX_RETRIES: %s", retries)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"test_task/internal/migrator"
+	"test_task/migrations"
+)
+
+const migrateUsage = "usage: migrate up | down [N] | status | goto N"
+
+// runMigrate executes the "migrate" subcommand with the arguments that follow it.
+func runMigrate(ctx context.Context, db *sql.DB, args []string) error {
+	m, err := migrator.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+		}
+		return m.Down(ctx, steps)
+	case "goto":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version: %s", args[1])
+		}
+		return m.Goto(ctx, uint(version))
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stdout, "current version: %d (dirty: %t)\n", status.Version, status.Dirty)
+		for _, mig := range status.Migrations {
+			state := "pending"
+			if mig.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(os.Stdout, "%03d_%s\t%s\n", mig.Version, mig.Name, state)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"test_task/internal/entity"
+	"test_task/internal/notify"
+	"test_task/internal/repository"
+	"test_task/internal/scheduler"
+	"test_task/internal/service"
+	"time"
+)
+
+// notifiersFromEnv builds the notification backends keyed by channel and
+// returns them with the default channel. The log channel is always
+// available; it writes to NOTIFY_LOG_FILE when that is set.
+func notifiersFromEnv() (map[string]notify.Notifier, string, error) {
+	channels := map[string]notify.Notifier{
+		entity.NotificationChannelLog:     notify.NewLogNotifier(),
+		entity.NotificationChannelWebhook: notify.NewWebhookNotifier(os.Getenv("NOTIFY_WEBHOOK_URL")),
+	}
+
+	if path := os.Getenv("NOTIFY_LOG_FILE"); path != "" {
+		channels[entity.NotificationChannelLog] = notify.NewFileNotifier(path)
+	}
+
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		channels[entity.NotificationChannelEmail] = notify.NewSMTPNotifier(
+			addr,
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			os.Getenv("SMTP_FROM"),
+		)
+	}
+
+	defaultChannel := os.Getenv("NOTIFY_DEFAULT_CHANNEL")
+	if defaultChannel == "" {
+		defaultChannel = entity.NotificationChannelLog
+	}
+	if _, ok := channels[defaultChannel]; !ok {
+		return nil, "", fmt.Errorf("invalid NOTIFY_DEFAULT_CHANNEL: %s", defaultChannel)
+	}
+
+	return channels, defaultChannel, nil
+}
+
+// durationFromEnv reads a positive duration such as "10s" or "1h", falling
+// back to def when the variable is not set.
+func durationFromEnv(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s: %s", name, v)
+	}
+
+	return d, nil
+}
+
+// webhookRetriesFromEnv reads how many times a webhook delivery is attempted
+// and the delay before the first retry.
+func webhookRetriesFromEnv() (int, time.Duration, error) {
+	maxAttempts := 8
+	if v := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: %s", v)
+		}
+		maxAttempts = n
+	}
+
+	retryBase, err := durationFromEnv("WEBHOOK_RETRY_BASE", 30*time.Second)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return maxAttempts, retryBase, nil
+}
+
+// newScheduler sets up the background jobs: renewal reminders, budget
+// alerts for budgets nobody looks at, monthly statements, webhook
+// deliveries and event log pruning.
+func newScheduler(db *sql.DB, subRepo *repository.SubscriptionRepository, notificationRepo *repository.NotificationRepository, notificationService *service.NotificationService, budgetService *service.BudgetService, statementService *service.StatementService, webhookService *service.WebhookService, eventService *service.EventService) (*scheduler.Scheduler, error) {
+	reminderInterval, err := durationFromEnv("REMINDER_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	reminderWindow, err := durationFromEnv("REMINDER_WINDOW", 72*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	budgetInterval, err := durationFromEnv("BUDGET_CHECK_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookInterval, err := durationFromEnv("WEBHOOK_DISPATCH_INTERVAL", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	reminders := service.NewReminderService(subRepo, notificationRepo, notificationService, reminderWindow)
+
+	sched := scheduler.New(db)
+	sched.Add(scheduler.Job{
+		Name:     "renewal_reminders",
+		Interval: reminderInterval,
+		Run: func(ctx context.Context) error {
+			return reminders.SendReminders(ctx, time.Now())
+		},
+	})
+	sched.Add(scheduler.Job{
+		Name:     "budget_alerts",
+		Interval: budgetInterval,
+		Run:      budgetService.EvaluateAll,
+	})
+	sched.Add(scheduler.Job{
+		Name:     "monthly_statements",
+		Interval: time.Hour,
+		Run:      statementService.CloseMonth,
+	})
+	sched.Add(scheduler.Job{
+		Name:     "webhook_dispatch",
+		Interval: webhookInterval,
+		Run:      webhookService.Dispatch,
+	})
+	sched.Add(scheduler.Job{
+		Name:     "event_log_prune",
+		Interval: time.Hour,
+		Run:      eventService.PruneEvents,
+	})
+
+	return sched, nil
+}
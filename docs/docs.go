@@ -23,9 +23,8 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscriptions": {
+        "/budgets": {
             "get": {
-                "description": "Show all existing subscriptions",
                 "consumes": [
                     "application/json"
                 ],
@@ -33,19 +32,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "budgets"
+                ],
+                "summary": "Get budgets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Only budgets of this user",
+                        "name": "user_id",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all subscriptions",
                 "responses": {
                     "200": {
-                        "description": "List of all subscriptions",
+                        "description": "Budgets",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/entity.Subscription"
+                                "$ref": "#/definitions/entity.Budget"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -55,6 +69,7 @@ const docTemplate = `{
                 }
             },
             "post": {
+                "description": "A budget limits the monthly or yearly spend of a user, a category or a service; scope fields left empty match everything",
                 "consumes": [
                     "application/json"
                 ],
@@ -62,23 +77,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "budgets"
                 ],
-                "summary": "Create a new subscription",
+                "summary": "Create a budget",
                 "parameters": [
                     {
-                        "description": "Subscription data",
-                        "name": "subscription",
+                        "description": "Budget",
+                        "name": "budget",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entity.Subscription"
+                            "$ref": "#/definitions/entity.Budget"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created"
+                        "description": "Created budget",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Budget"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -95,9 +113,8 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscriptions/total": {
+        "/budgets/{id}": {
             "get": {
-                "description": "Calculate total cost of subscriptions with optional filters",
                 "consumes": [
                     "application/json"
                 ],
@@ -105,47 +122,121 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "budgets"
                 ],
-                "summary": "Get total cost of subscriptions",
+                "summary": "Get a budget by id",
                 "parameters": [
                     {
-                        "type": "string",
-                        "format": "uuid",
-                        "description": "Filter by user ID",
-                        "name": "user_id",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Budget ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Budget",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Budget"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Filter by service name",
-                        "name": "service_name",
-                        "in": "query"
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "budgets"
+                ],
+                "summary": "Update a budget by id",
+                "parameters": [
                     {
-                        "type": "string",
-                        "format": "date",
-                        "description": "Filter by start date",
-                        "name": "from_date",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Budget ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "format": "date",
-                        "description": "Filter by end date",
-                        "name": "to_date",
-                        "in": "query"
+                        "description": "Budget",
+                        "name": "budget",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Budget"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Total cost",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "integer"
-                            }
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
                         }
+                    }
+                }
+            },
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "budgets"
+                ],
+                "summary": "Delete a budget by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Budget ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -153,6 +244,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -162,9 +259,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscriptions/{id}": {
+        "/budgets/{id}/status": {
             "get": {
-                "description": "Show an existing subscription by id",
+                "description": "Spend of the current budget period so far and projected to its end, with the alerts raised in the period. A background job raises an alert once per period when the spend reaches 80% and 100% of the budget.",
                 "consumes": [
                     "application/json"
                 ],
@@ -172,13 +269,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "budgets"
                 ],
-                "summary": "Get a subscription by id",
+                "summary": "Get the spend against a budget",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Subscription ID",
+                        "description": "Budget ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -186,9 +283,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Subscription found",
+                        "description": "Budget status",
                         "schema": {
-                            "$ref": "#/definitions/entity.Subscription"
+                            "$ref": "#/definitions/entity.BudgetStatus"
                         }
                     },
                     "400": {
@@ -197,6 +294,43 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/categories": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Get all categories",
+                "responses": {
+                    "200": {
+                        "description": "Categories",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Category"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -205,8 +339,7 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
-                "description": "Update an existing subscription by its ID",
+            "post": {
                 "consumes": [
                     "application/json"
                 ],
@@ -214,23 +347,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "categories"
                 ],
-                "summary": "Update a subscription by id",
+                "summary": "Create a category",
                 "parameters": [
                     {
-                        "description": "Subscription data",
-                        "name": "subscription",
+                        "description": "Category",
+                        "name": "category",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entity.Subscription"
+                            "$ref": "#/definitions/entity.Category"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "201": {
+                        "description": "Created category",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Category"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -238,6 +374,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -245,9 +387,10 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/categories/{name}": {
             "delete": {
-                "description": "Delete an existing subscription by its ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -255,14 +398,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "categories"
                 ],
-                "summary": "Delete a subscription by id",
+                "summary": "Delete an unused category",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Subscription ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Category name",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
@@ -271,8 +414,14 @@ const docTemplate = `{
                     "204": {
                         "description": "No Content"
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "string"
                         }
@@ -285,37 +434,4261 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "entity.Subscription": {
-            "type": "object",
-            "properties": {
-                "end_date": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "price": {
-                    "type": "integer"
-                },
-                "service_name": {
-                    "type": "string"
-                },
-                "start_date": {
-                    "type": "string"
-                },
-                "user_id": {
-                    "type": "string"
-                }
-            }
-        }
-    },
-    "tags": [
-        {
-            "description": "Subscription management endpoints",
-            "name": "subscriptions"
+        },
+        "/reports/anomalies": {
+            "get": {
+                "description": "Unusual findings in a month, most severe first: monthly spend of a user or on a service, counting the monthly equivalents of quarterly and yearly subscriptions, rising from the month before by at least jump_percent, subscriptions priced at least price_factor times the median of the same service, as monthly equivalents of their billing periods (with at least 3 subscriptions to compare), and subscriptions still active forgotten_months after most subscriptions to the same service typically ended.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Spending anomalies",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Only findings of this user, whose spend counts their share of shared subscriptions",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only findings on subscriptions to this service or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only findings on subscriptions of this category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only findings on subscriptions with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Month to check: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY (defaults to the current month); from_date and to_date are rejected",
+                        "name": "month",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Month-over-month increase flagged, in percent (default 50)",
+                        "name": "jump_percent",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Multiple of the service median flagged (default 2)",
+                        "name": "price_factor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Months after the typical cancellation flagged (default 6)",
+                        "name": "forgotten_months",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.AnomalyReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/average-prices": {
+            "get": {
+                "description": "Average, lowest and highest list price of each service over the months the matching subscriptions were active in a period, scheduled price changes included and trials and promotions left out; most expensive first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Average price per service",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of services, 1 to 100 (default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month of the period, same formats (defaults to the current month)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.ServicePrice"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/churn": {
+            "get": {
+                "description": "Cancellations ending within a period, by default the last twelve months, counted by service and reason. lost_monthly sums the monthly prices of the cancelled subscriptions.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Churn report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Only cancellations of this user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month of the period, same formats (defaults to the current month with basis=charges)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ChurnReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/common-services": {
+            "get": {
+                "description": "Rank services by how many matching subscriptions are active at least one month of a period.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Most common services",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of services, 1 to 100 (default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month of the period, same formats (defaults to the current month)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.ServicePopularity"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/overlaps": {
+            "get": {
+                "description": "Pairs of subscriptions of the same user to the same canonical service that share at least one month, with the months they share; one of each pair is likely a duplicate.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Overlapping subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Only overlaps of this user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Overlap"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/retention": {
+            "get": {
+                "description": "Group the matching subscriptions by start month (cohort), optionally per service or category, and report for each month since the start, up to the current month, how many of them and which fraction are still active according to their start and end dates.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Retention of subscription cohorts",
+                "parameters": [
+                    {
+                        "enum": [
+                            "service",
+                            "category"
+                        ],
+                        "type": "string",
+                        "description": "Follow the cohorts of each service or category separately",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of months to follow each cohort for, 1 to 120 (default 12)",
+                        "name": "months",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First cohort month: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY (defaults to eleven months before to_date)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last cohort month, same formats (defaults to the current month)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.RetentionReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/settlement": {
+            "get": {
+                "description": "Who owes whom for the shared subscriptions within a period, by default the current month: every sharing user owes the paying user their share. Debts between two users are netted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Settlement of shared subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Only debts of or to this user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only subscriptions of this service or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only subscriptions of this category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only subscriptions with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY (defaults to to_date)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month of the period, same formats (defaults to the current month)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Settlement"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/top-services": {
+            "get": {
+                "description": "Rank services by what the matching subscriptions charged over a period, counted as by /subscriptions/total. With user_id, only the user's share of shared subscriptions counts.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Top services by spend",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of services, 1 to 100 (default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month of the period, same formats (defaults to the current month)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.ServiceSpend"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/top-users": {
+            "get": {
+                "description": "Rank users by what they paid for the matching subscriptions over a period, counting their share of shared subscriptions.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Top users by spend",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of users, 1 to 100 (default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month of the period, same formats (defaults to the current month)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.UserSpend"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/services": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Get the service catalog",
+                "responses": {
+                    "200": {
+                        "description": "Catalog entries",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Service"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Existing subscriptions named like the service or one of its aliases are linked to it, take its category when they have none and publish subscription.updated events",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Add a service to the catalog",
+                "parameters": [
+                    {
+                        "description": "Catalog entry",
+                        "name": "service",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Service"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created catalog entry",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Service"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/{id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Get a catalog entry by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Catalog entry",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Service"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Linked subscriptions are renamed to the new canonical name, subscriptions without a category take the service category, and each changed subscription publishes a subscription.updated event",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Update a catalog entry by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Catalog entry",
+                        "name": "service",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Service"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Linked subscriptions keep their names and are unlinked",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Delete a catalog entry by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "description": "Show existing subscriptions with optional filters, in id order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get all subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only subscriptions active in or after this month",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only subscriptions active in or before this month",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter expression comparing the fields id, price, service_name, category, billing_period, user_id, start_date and end_date with literals (strings and months double-quoted, * and ? wildcards with ~ and !~) and calling active_in, has_tag and shared, combined with and, or, not and parentheses",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 1 to 100 (default: all subscriptions)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of subscriptions to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of all subscriptions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Subscription"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Depending on OVERLAP_POLICY, a subscription sharing months with another one of the same user to the same service is rejected with 409, or stored with the ids of the overlapping subscriptions in the X-Overlapping-Subscriptions header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Create a new subscription",
+                "parameters": [
+                    {
+                        "description": "Subscription data",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "headers": {
+                            "X-Overlapping-Subscriptions": {
+                                "type": "string",
+                                "description": "Ids of the overlapping subscriptions"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/autocomplete": {
+            "get": {
+                "description": "Distinct service names of the subscriptions starting with the text, then those containing or resembling it; names differing only in case are suggested once. Takes the filters and pagination of the listing.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Autocomplete service names",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Text typed so far",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of names, 1 to 100 (default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of names to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Only names of this user's subscriptions",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only subscriptions active in or after this month",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only subscriptions active in or before this month",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Service names",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/events": {
+            "get": {
+                "description": "Server-Sent Events stream of subscription.created, subscription.updated and subscription.deleted events; the data of an event is the subscription. A client reconnecting with Last-Event-ID first gets the events it missed that are still in the event log; a \"reset\" event tells it that some may be lost.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Stream subscription changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Only events of this user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events of this service or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/forecast": {
+            "get": {
+                "description": "Project what subscriptions will charge month by month, starting with the next month, from their end dates, trials, promotions, pauses, billing periods and scheduled price changes as known today. With user_id, shared subscriptions count for the user's share only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Forecast spend",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of months to forecast, 1 to 60 (default 3)",
+                        "name": "months",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "category",
+                            "tag",
+                            "service",
+                            "user"
+                        ],
+                        "type": "string",
+                        "description": "Break every month down by category, tag, canonical service or user",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Forecast"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/search": {
+            "get": {
+                "description": "Find the subscriptions whose service name contains the text or resembles it, tolerating typos such as netflx for Netflix, most similar first. Takes the filters and pagination of the listing.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Search subscriptions by service name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Text to search for",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 1 to 100 (default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of subscriptions to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only subscriptions active in or after this month",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only subscriptions active in or before this month",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter expression comparing the fields id, price, service_name, category, billing_period, user_id, start_date and end_date with literals (strings and months double-quoted, * and ? wildcards with ~ and !~) and calling active_in, has_tag and shared, combined with and, or, not and parentheses",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matching subscriptions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Subscription"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/total": {
+            "get": {
+                "description": "Total the subscriptions with optional filters. With basis=price, the default, the price of each subscription starting in or after from_date and ending by to_date is added once. With basis=charges, every month a subscription is billed in within the period adds the amount charged that month; the period then ends with to_date, or the current month without it. The response states the basis and period used. With user_id, shared subscriptions count for the user's share only; grouping by user splits shared subscriptions between their users.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get total cost of subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month of the period, same formats (defaults to the current month)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter expression comparing the fields id, price, service_name, category, billing_period, user_id, start_date and end_date with literals (strings and months double-quoted, * and ? wildcards with ~ and !~) and calling active_in, has_tag and shared, combined with and, or, not and parentheses",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "category",
+                            "tag",
+                            "service",
+                            "user"
+                        ],
+                        "type": "string",
+                        "description": "Break the total down by category, tag, canonical service or user",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "price",
+                            "charges"
+                        ],
+                        "type": "string",
+                        "description": "What is added up: the price of each subscription within the dates once (default) or the monthly charges over the period",
+                        "name": "basis",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Total cost",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TotalCost"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/trials": {
+            "get": {
+                "description": "Subscriptions whose first paid month after a free trial starts within the given number of days",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get trials converting to paid soon",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "How many days ahead to look (default 7, at most 366)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trial conversions, soonest first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.TrialConversion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "description": "Show an existing subscription by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get a subscription by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscription found",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update an existing subscription by its ID. Overlaps with other subscriptions are handled as on creation.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Update a subscription by id",
+                "parameters": [
+                    {
+                        "description": "Subscription data",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "headers": {
+                            "X-Overlapping-Subscriptions": {
+                                "type": "string",
+                                "description": "Ids of the overlapping subscriptions"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an existing subscription by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Delete a subscription by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/cancel": {
+            "post": {
+                "description": "End a subscription with a month, by default the end of the current billing period, and record why. The reason is one of too_expensive, not_using, switched_service, technical_issues, temporary or other; other needs a comment. Cancelling again replaces the earlier cancellation.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Cancel a subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Last billed month and reason",
+                        "name": "cancel",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.CancelRequest"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cancelled subscription",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/pause": {
+            "post": {
+                "description": "Stop billing from a month (the current one by default) through another, or until resumed. Pauses must lie within the subscription and must not overlap.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Pause a subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Paused months",
+                        "name": "pause",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/entity.PauseRequest"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paused subscription",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/resume": {
+            "post": {
+                "description": "End the open pause so that billing restarts in a month, the current one by default. The month should not be in the past, before the pause or after the subscription ends.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Resume a paused subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "First billed month",
+                        "name": "resume",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ResumeRequest"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resumed subscription",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get all users",
+                "responses": {
+                    "200": {
+                        "description": "List of all users",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.User"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a user; the id is generated unless given",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created user",
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a user by id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User found",
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update a user by id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a user that has no subscriptions, shares no subscriptions and has no statements, with their budgets and notification preferences. Other users can be made inactive instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete a user by id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/calendar-token": {
+            "post": {
+                "description": "Generate the secret token authenticating the calendar feed of a user, revoking the previous one, which has to be given to replace it. The token is only returned here.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Issue a calendar token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Current calendar token, required once one was issued",
+                        "name": "X-Calendar-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/entity.CalendarToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/calendar.ics": {
+            "get": {
+                "description": "iCalendar (RFC 5545) feed with an all-day event on each upcoming billing date of the subscriptions the user pays or shares, and on the last day of each of their subscriptions that ends, prices in the descriptions. Authenticated by the token from /users/{id}/calendar-token; a wrong token answers 404.",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Calendar feed of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Calendar token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of months ahead, the current one included, 1 to 36 (default 12)",
+                        "name": "months",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "iCalendar feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/notification-preferences": {
+            "get": {
+                "description": "Users that have not set any get the server defaults",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get notification preferences of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notification preferences",
+                        "schema": {
+                            "$ref": "#/definitions/entity.NotificationPreferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Email notifications go to the email of the user; channels that cannot reach the user fall back to the server default. A webhook_url has to resolve to public addresses only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Set notification preferences of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Notification preferences",
+                        "name": "preferences",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.NotificationPreferences"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/statements": {
+            "get": {
+                "description": "Generated statements of a user without their charges, latest month first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List the statements of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.StatementSummary"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Build and store the statement of a user for a past month: every charge of the subscriptions they pay or share, the total, the change from the month before and the status of their budgets. A statement is generated once and never changes afterwards, even when subscriptions are edited. The scheduler stores the statements of active users as soon as a month is over; a statement generated here reflects the subscriptions and budgets as they are now, including edits made since the month closed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Generate a monthly statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Statement month",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.StatementRequest"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Statement"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/statements/{month}": {
+            "get": {
+                "description": "Stored statement of a user for a month, as JSON, an HTML page or a PDF document",
+                "produces": [
+                    "application/json",
+                    "text/html",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a monthly statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "06-2025",
+                        "description": "Statement month, MM-YYYY or YYYY-MM",
+                        "name": "month",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "html",
+                            "pdf"
+                        ],
+                        "type": "string",
+                        "description": "Document format (default json)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Statement"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/subscriptions": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get subscriptions of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "mm-yyyy",
+                            "iso"
+                        ],
+                        "type": "string",
+                        "description": "Date format of the response: mm-yyyy (default) or iso",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscriptions of the user",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Subscription"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/total": {
+            "get": {
+                "description": "Same as /subscriptions/total restricted to one user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get total cost of a user's subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name or catalog alias",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month of the period, same formats (defaults to the current month)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Read from the primary database instead of a replica",
+                        "name": "X-Read-Your-Writes",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Total cost",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get all webhooks",
+                "responses": {
+                    "200": {
+                        "description": "Webhooks",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Webhook"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "The webhook receives the chosen event types, all of them when none are given. Its URL has to resolve to public addresses only. Deliveries are signed: X-Webhook-Signature is \"sha256=\" and the hex HMAC-SHA256 of X-Webhook-Timestamp, \".\" and the body, keyed with the secret. Without a secret one is generated; it is only returned here.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Register a webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Webhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created webhook with its secret",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get a webhook by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "The secret is kept unless a new one is given. The URL has to resolve to public addresses only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Update a webhook by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Webhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Latest deliveries first. Failed attempts are retried with exponential backoff until the delivery succeeds or fails for good.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get the delivery log of a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "succeeded",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Only deliveries with this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of deliveries (default 50, at most 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deliveries",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "entity.Anomaly": {
+            "type": "object",
+            "properties": {
+                "baseline": {
+                    "type": "integer"
+                },
+                "current": {
+                    "type": "integer"
+                },
+                "explanation": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "spend_jump",
+                        "price_outlier",
+                        "forgotten"
+                    ]
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "severity": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "medium",
+                        "high"
+                    ]
+                },
+                "subscription_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.AnomalyReport": {
+            "type": "object",
+            "properties": {
+                "anomalies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Anomaly"
+                    }
+                },
+                "month": {
+                    "type": "string",
+                    "example": "06-2025"
+                }
+            }
+        },
+        "entity.Budget": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 2000
+                },
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Streaming"
+                },
+                "period": {
+                    "type": "string",
+                    "enum": [
+                        "monthly",
+                        "yearly"
+                    ]
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.BudgetAlert": {
+            "type": "object",
+            "properties": {
+                "budget_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "period_start": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "spent": {
+                    "type": "integer"
+                },
+                "threshold": {
+                    "type": "integer",
+                    "example": 80
+                }
+            }
+        },
+        "entity.BudgetStatus": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.BudgetAlert"
+                    }
+                },
+                "amount": {
+                    "type": "integer"
+                },
+                "budget_id": {
+                    "type": "integer"
+                },
+                "period_end": {
+                    "type": "string",
+                    "example": "12-2025"
+                },
+                "period_start": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "projected": {
+                    "description": "Projected is what the subscriptions known now charge over the whole period.",
+                    "type": "integer"
+                },
+                "projected_percent": {
+                    "type": "integer"
+                },
+                "spent": {
+                    "description": "Spent is charged from the start of the period through the current month.",
+                    "type": "integer"
+                },
+                "spent_percent": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.CalendarToken": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "URL is the path of the feed, token included.",
+                    "type": "string",
+                    "example": "/users/0b6e.../calendar.ics?token=..."
+                }
+            }
+        },
+        "entity.CancelRequest": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "example": "Moving to a family plan"
+                },
+                "effective_month": {
+                    "description": "EffectiveMonth is the last billed month, by default the end of the\ncurrent billing period.",
+                    "type": "string",
+                    "example": "12-2025"
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "too_expensive",
+                        "not_using",
+                        "switched_service",
+                        "technical_issues",
+                        "temporary",
+                        "other"
+                    ]
+                }
+            }
+        },
+        "entity.Category": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "streaming"
+                }
+            }
+        },
+        "entity.ChurnGroup": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "lost_monthly": {
+                    "description": "LostMonthly is the sum of the monthly prices of the cancelled\nsubscriptions.",
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "service_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.ChurnReport": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.ChurnGroup"
+                    }
+                },
+                "lost_monthly": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "12-2025"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.CostBasis": {
+            "type": "string",
+            "enum": [
+                "price",
+                "charges"
+            ],
+            "x-enum-varnames": [
+                "CostBasisPrice",
+                "CostBasisCharges"
+            ]
+        },
+        "entity.CostGroup": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Debt": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "from_user_id": {
+                    "type": "string"
+                },
+                "to_user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Forecast": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "01-2026"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.CostGroup"
+                    }
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.ForecastPoint"
+                    }
+                },
+                "to": {
+                    "type": "string",
+                    "example": "03-2026"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.ForecastPoint": {
+            "type": "object",
+            "properties": {
+                "cumulative": {
+                    "description": "Cumulative is the spend from the first month of the forecast through\nthis one.",
+                    "type": "integer"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.CostGroup"
+                    }
+                },
+                "month": {
+                    "type": "string",
+                    "example": "01-2026"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.NotificationPreferences": {
+            "type": "object",
+            "properties": {
+                "budget_alerts": {
+                    "type": "boolean"
+                },
+                "channel": {
+                    "type": "string",
+                    "enum": [
+                        "log",
+                        "email",
+                        "webhook"
+                    ]
+                },
+                "reminder_days": {
+                    "description": "ReminderDays is how many days ahead reminders are sent; nil means the\nserver default.",
+                    "type": "integer",
+                    "example": 3
+                },
+                "renewal_reminders": {
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "webhook_url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/subscriptions"
+                }
+            }
+        },
+        "entity.Overlap": {
+            "type": "object",
+            "properties": {
+                "first_id": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "03-2025"
+                },
+                "second_id": {
+                    "type": "integer"
+                },
+                "service_id": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "06-2025"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Pause": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "03-2025"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "05-2025"
+                }
+            }
+        },
+        "entity.PauseRequest": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "From is the first paused month, the current month by default.",
+                    "type": "string",
+                    "example": "03-2025"
+                },
+                "to": {
+                    "description": "To is the last paused month; without it the subscription stays paused\nuntil resumed.",
+                    "type": "string",
+                    "example": "05-2025"
+                }
+            }
+        },
+        "entity.PriceChange": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "01-2026"
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 499
+                }
+            }
+        },
+        "entity.ResumeRequest": {
+            "type": "object",
+            "properties": {
+                "month": {
+                    "description": "Month is the first month billed again, the current month by default.",
+                    "type": "string",
+                    "example": "06-2025"
+                }
+            }
+        },
+        "entity.RetentionCohort": {
+            "type": "object",
+            "properties": {
+                "cohort": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "key": {
+                    "description": "Key is the service or category of the group, empty without grouping\nor for subscriptions without a category.",
+                    "type": "string"
+                },
+                "retention": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.RetentionPoint"
+                    }
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.RetentionPoint": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "description": "Offset is the number of months since the cohort month, 0 being the\ncohort month itself.",
+                    "type": "integer"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 0.75
+                }
+            }
+        },
+        "entity.RetentionReport": {
+            "type": "object",
+            "properties": {
+                "cohorts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.RetentionCohort"
+                    }
+                },
+                "from": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "group_by": {
+                    "type": "string",
+                    "enum": [
+                        "",
+                        "service",
+                        "category"
+                    ]
+                },
+                "to": {
+                    "type": "string",
+                    "example": "12-2025"
+                }
+            }
+        },
+        "entity.Service": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "yandex plus",
+                        "Яндекс Плюс"
+                    ]
+                },
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "default_price": {
+                    "type": "integer",
+                    "example": 399
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "vendor_url": {
+                    "type": "string",
+                    "example": "https://plus.yandex.ru"
+                }
+            }
+        },
+        "entity.ServicePopularity": {
+            "type": "object",
+            "properties": {
+                "service_name": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.ServicePrice": {
+            "type": "object",
+            "properties": {
+                "average": {
+                    "type": "number",
+                    "example": 349.5
+                },
+                "max": {
+                    "type": "integer"
+                },
+                "min": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.ServiceSpend": {
+            "type": "object",
+            "properties": {
+                "service_name": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Settlement": {
+            "type": "object",
+            "properties": {
+                "debts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Debt"
+                    }
+                },
+                "from": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "03-2025"
+                }
+            }
+        },
+        "entity.Share": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 150
+                },
+                "percent": {
+                    "type": "integer",
+                    "example": 25
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Statement": {
+            "type": "object",
+            "properties": {
+                "budgets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.StatementBudget"
+                    }
+                },
+                "change": {
+                    "type": "integer"
+                },
+                "change_percent": {
+                    "description": "ChangePercent is nil when nothing was charged in the month before.",
+                    "type": "integer"
+                },
+                "charges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.StatementCharge"
+                    }
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "06-2025"
+                },
+                "previous_total": {
+                    "description": "PreviousTotal is what the user was charged in the month before, as\ncomputed when the statement was generated.",
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.StatementBudget": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "budget_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "period": {
+                    "type": "string",
+                    "enum": [
+                        "monthly",
+                        "yearly"
+                    ]
+                },
+                "period_end": {
+                    "type": "string",
+                    "example": "12-2025"
+                },
+                "period_start": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "spent": {
+                    "type": "integer"
+                },
+                "spent_percent": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.StatementCharge": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "price": {
+                    "description": "Price is what the subscription charged and Amount the part of it the\nuser pays.",
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "shared": {
+                    "description": "Shared is set when the subscription is split with other users.",
+                    "type": "boolean"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.StatementRequest": {
+            "type": "object",
+            "properties": {
+                "month": {
+                    "type": "string",
+                    "example": "06-2025"
+                }
+            }
+        },
+        "entity.StatementSummary": {
+            "type": "object",
+            "properties": {
+                "generated_at": {
+                    "type": "string"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "06-2025"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Subscription": {
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "description": "BillingPeriod is monthly, quarterly or yearly; Price is charged once a\nperiod.",
+                    "type": "string",
+                    "enum": [
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ],
+                    "example": "monthly"
+                },
+                "category": {
+                    "description": "Category defaults to the category of the linked catalog entry.",
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "12-2025"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "pauses": {
+                    "description": "Pauses is the pause history, oldest first. It is managed through the\npause and resume endpoints and ignored on writes.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Pause"
+                    }
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "price_changes": {
+                    "description": "PriceChanges are the scheduled changes of Price, oldest first.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.PriceChange"
+                    }
+                },
+                "promo_months": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "promo_price": {
+                    "description": "PromoPrice is charged for PromoMonths months after the trial, if any.",
+                    "type": "integer",
+                    "example": 199
+                },
+                "service_id": {
+                    "description": "ServiceId links the subscription to the service catalog when its name\nmatches a catalog entry; ServiceName then holds the canonical name.",
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "shares": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Share"
+                    }
+                },
+                "split_rule": {
+                    "description": "SplitRule is equal, percentage or fixed; it is required with Shares.",
+                    "type": "string",
+                    "enum": [
+                        "equal",
+                        "percentage",
+                        "fixed"
+                    ]
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "07-2025"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "family",
+                        "work"
+                    ]
+                },
+                "trial_end": {
+                    "description": "TrialEnd is the last month of a free trial.",
+                    "type": "string",
+                    "example": "07-2025"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.TotalCost": {
+            "type": "object",
+            "properties": {
+                "basis": {
+                    "enum": [
+                        "price",
+                        "charges"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/entity.CostBasis"
+                        }
+                    ]
+                },
+                "from": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.CostGroup"
+                    }
+                },
+                "to": {
+                    "type": "string",
+                    "example": "12-2025"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.TrialConversion": {
+            "type": "object",
+            "properties": {
+                "converts_in": {
+                    "description": "ConvertsIn is the first paid month.",
+                    "type": "string",
+                    "example": "09-2025"
+                },
+                "first_charge": {
+                    "description": "FirstCharge is what is charged in that month.",
+                    "type": "integer",
+                    "example": 199
+                },
+                "subscription": {
+                    "$ref": "#/definitions/entity.Subscription"
+                }
+            }
+        },
+        "entity.User": {
+            "type": "object",
+            "properties": {
+                "default_currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "inactive"
+                    ]
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Moscow"
+                }
+            }
+        },
+        "entity.UserSpend": {
+            "type": "object",
+            "properties": {
+                "subscriptions": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Webhook": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "subscription.created",
+                        "subscription.deleted"
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks"
+                }
+            }
+        },
+        "entity.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "response_status": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "succeeded",
+                        "failed"
+                    ]
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        }
+    },
+    "tags": [
+        {
+            "description": "Subscription management endpoints",
+            "name": "subscriptions"
+        },
+        {
+            "description": "User management endpoints",
+            "name": "users"
+        },
+        {
+            "description": "Service catalog endpoints",
+            "name": "services"
+        },
+        {
+            "description": "Subscription categories",
+            "name": "categories"
+        },
+        {
+            "description": "Spending budgets and alerts",
+            "name": "budgets"
+        },
+        {
+            "description": "Outgoing webhooks for subscription events",
+            "name": "webhooks"
+        },
+        {
+            "description": "Reports over subscriptions and cancellations",
+            "name": "reports"
         }
     ]
 }`
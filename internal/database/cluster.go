@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Cluster is a primary database with optional read replicas. Reads that can
+// tolerate replication lag go to a healthy replica chosen round-robin and
+// fall back to the primary when no replica is available.
+type Cluster struct {
+	primary  *sql.DB
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+type replica struct {
+	db      *sql.DB
+	index   int
+	healthy atomic.Bool
+}
+
+type primaryKey struct{}
+
+func NewCluster(primary *sql.DB) *Cluster {
+	return &Cluster{primary: primary}
+}
+
+// InitCluster connects to the replicas in addition to an already open
+// primary. A replica that cannot be reached yet is kept and marked unhealthy
+// until a health check succeeds.
+func InitCluster(primary *sql.DB, replicaConnStrs []string) (*Cluster, error) {
+	c := NewCluster(primary)
+
+	for i, connStr := range replicaConnStrs {
+		db, err := openDB(connStr)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+
+		r := &replica{db: db, index: i}
+		if err := db.Ping(); err != nil {
+			slog.Warn("Реплика БД недоступна", "replica", i, "error", err)
+		} else {
+			r.healthy.Store(true)
+		}
+		c.replicas = append(c.replicas, r)
+	}
+
+	if len(c.replicas) > 0 {
+		slog.Info("Реплики БД подключены", "count", len(c.replicas))
+	}
+
+	return c, nil
+}
+
+func (c *Cluster) Primary() *sql.DB {
+	return c.primary
+}
+
+// Reader returns the database to run a read-only query on.
+func (c *Cluster) Reader(ctx context.Context) *sql.DB {
+	if len(c.replicas) == 0 || UsesPrimary(ctx) {
+		return c.primary
+	}
+
+	start := c.next.Add(1)
+	for i := range c.replicas {
+		r := c.replicas[(start+uint64(i))%uint64(len(c.replicas))]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+
+	return c.primary
+}
+
+// StartHealthChecks pings every replica at the given interval until ctx is
+// cancelled, taking failing replicas out of rotation and returning recovered
+// ones to it.
+func (c *Cluster) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	if len(c.replicas) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, r := range c.replicas {
+					c.check(ctx, r, interval)
+				}
+			}
+		}
+	}()
+}
+
+func (c *Cluster) check(ctx context.Context, r *replica, timeout time.Duration) {
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := r.db.PingContext(pingCtx)
+	healthy := err == nil
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+
+	if healthy {
+		slog.Info("Реплика БД снова доступна", "replica", r.index)
+	} else {
+		slog.Warn("Реплика БД выведена из ротации", "replica", r.index, "error", err)
+	}
+}
+
+func (c *Cluster) Close() {
+	for _, r := range c.replicas {
+		r.db.Close()
+	}
+}
+
+// WithPrimary marks ctx so that reads made with it go to the primary, for
+// clients that must see their own writes.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func UsesPrimary(ctx context.Context) bool {
+	usePrimary, _ := ctx.Value(primaryKey{}).(bool)
+	return usePrimary
+}
@@ -14,15 +14,11 @@ func InitDB(connStr string) (*sql.DB, error) {
 		return nil, fmt.Errorf("Строка подключения не должна быть пустой")
 	}
 
-	db, err := sql.Open("postgres", connStr)
+	db, err := openDB(connStr)
 	if err != nil {
-		return nil, fmt.Errorf("Ошибка подключения к БД: %w", err)
+		return nil, err
 	}
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(5 * time.Minute)
-
 	err = db.Ping()
 	if err != nil {
 		return nil, fmt.Errorf("Ошибка ping БД: %w", err)
@@ -32,6 +28,19 @@ func InitDB(connStr string) (*sql.DB, error) {
 	return db, nil
 }
 
+func openDB(connStr string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка подключения к БД: %w", err)
+	}
+
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(25)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	return db, nil
+}
+
 func CloseDB(db *sql.DB) {
 	if db != nil {
 		db.Close()
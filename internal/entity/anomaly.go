@@ -0,0 +1,63 @@
+package entity
+
+import "github.com/google/uuid"
+
+// Kinds of anomalies.
+const (
+	// AnomalySpendJump is spend rising month over month by more than the
+	// threshold, for a user or a service.
+	AnomalySpendJump = "spend_jump"
+	// AnomalyPriceOutlier is a subscription priced far above the median of
+	// the same service.
+	AnomalyPriceOutlier = "price_outlier"
+	// AnomalyForgotten is a subscription still active long after most
+	// subscriptions to the same service were cancelled.
+	AnomalyForgotten = "forgotten"
+)
+
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// Anomaly is an unusual finding. Current is the spend or price found and
+// Baseline what it is compared with.
+type Anomaly struct {
+	Kind           string     `json:"kind" enums:"spend_jump,price_outlier,forgotten"`
+	Severity       string     `json:"severity" enums:"low,medium,high"`
+	UserId         *uuid.UUID `json:"user_id"`
+	ServiceName    string     `json:"service_name,omitempty"`
+	SubscriptionId *int       `json:"subscription_id,omitempty"`
+	Current        int        `json:"current"`
+	Baseline       int        `json:"baseline"`
+	Explanation    string     `json:"explanation"`
+}
+
+// AnomalyOptions tunes the anomaly detection.
+type AnomalyOptions struct {
+	// JumpPercent is the month-over-month spend increase flagged.
+	JumpPercent int
+	// PriceFactor is how many times the service median a price has to be to
+	// be flagged.
+	PriceFactor float64
+	// ForgottenMonths is how long after the typical cancellation of a
+	// service a subscription to it has to stay active to be flagged.
+	ForgottenMonths int
+}
+
+// DefaultAnomalyOptions flags spend rising by half, prices twice the median
+// and subscriptions active half a year after the typical cancellation.
+func DefaultAnomalyOptions() AnomalyOptions {
+	return AnomalyOptions{
+		JumpPercent:     50,
+		PriceFactor:     2,
+		ForgottenMonths: 6,
+	}
+}
+
+// AnomalyReport lists the anomalies found in Month, most severe first.
+type AnomalyReport struct {
+	Month     YearMonth `json:"month" swaggertype:"string" example:"06-2025"`
+	Anomalies []Anomaly `json:"anomalies"`
+}
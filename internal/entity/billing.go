@@ -0,0 +1,51 @@
+package entity
+
+import "fmt"
+
+// Billing periods. A subscription is charged in the first month of each
+// period, counted from its first paid month.
+const (
+	BillingMonthly   = "monthly"
+	BillingQuarterly = "quarterly"
+	BillingYearly    = "yearly"
+)
+
+// BillingPeriodMonths returns the length of a billing period in months.
+func BillingPeriodMonths(period string) (int, error) {
+	switch period {
+	case BillingMonthly:
+		return 1, nil
+	case BillingQuarterly:
+		return 3, nil
+	case BillingYearly:
+		return 12, nil
+	default:
+		return 0, fmt.Errorf("unknown billing period %q: expected monthly, quarterly or yearly", period)
+	}
+}
+
+// PriceChange sets the price of a subscription from a month on.
+type PriceChange struct {
+	From  YearMonth `json:"from" swaggertype:"string" example:"01-2026"`
+	Price int       `json:"price" example:"499"`
+}
+
+// ForecastPoint is the projected spend of one month.
+type ForecastPoint struct {
+	Month YearMonth `json:"month" swaggertype:"string" example:"01-2026"`
+	Total int       `json:"total"`
+	// Cumulative is the spend from the first month of the forecast through
+	// this one.
+	Cumulative int         `json:"cumulative"`
+	Groups     []CostGroup `json:"groups,omitempty"`
+}
+
+// Forecast projects the spend of the subscriptions as known today from From
+// through To.
+type Forecast struct {
+	From   YearMonth       `json:"from" swaggertype:"string" example:"01-2026"`
+	To     YearMonth       `json:"to" swaggertype:"string" example:"03-2026"`
+	Total  int             `json:"total"`
+	Groups []CostGroup     `json:"groups,omitempty"`
+	Months []ForecastPoint `json:"months"`
+}
@@ -0,0 +1,77 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	BudgetPeriodMonthly = "monthly"
+	BudgetPeriodYearly  = "yearly"
+)
+
+// BudgetThresholds are the percentages of a budget at which alerts are raised.
+var BudgetThresholds = []int{80, 100}
+
+// Budget limits the spend of the subscriptions matching its scope over a
+// calendar month or year. Empty scope fields match everything, so a budget
+// without any is a global one.
+type Budget struct {
+	Id          int        `json:"id"`
+	Name        string     `json:"name" example:"Streaming"`
+	UserId      *uuid.UUID `json:"user_id"`
+	Category    *string    `json:"category" example:"streaming"`
+	ServiceName *string    `json:"service_name"`
+	Period      string     `json:"period" enums:"monthly,yearly"`
+	Amount      int        `json:"amount" example:"2000"`
+}
+
+// PeriodAt returns the first and last month of the budget period containing
+// the given month.
+func (b Budget) PeriodAt(month YearMonth) (YearMonth, YearMonth) {
+	if b.Period == BudgetPeriodYearly {
+		return NewYearMonth(month.Year, time.January), NewYearMonth(month.Year, time.December)
+	}
+
+	return month, month
+}
+
+// Filter returns the subscription filter matching the budget scope.
+func (b Budget) Filter() SubscriptionFilter {
+	var filter SubscriptionFilter
+	if b.UserId != nil {
+		filter.UserId = *b.UserId
+	}
+	if b.Category != nil {
+		filter.Category = *b.Category
+	}
+	if b.ServiceName != nil {
+		filter.ServiceName = *b.ServiceName
+	}
+
+	return filter
+}
+
+type BudgetStatus struct {
+	BudgetId    int       `json:"budget_id"`
+	PeriodStart YearMonth `json:"period_start" swaggertype:"string" example:"01-2025"`
+	PeriodEnd   YearMonth `json:"period_end" swaggertype:"string" example:"12-2025"`
+	Amount      int       `json:"amount"`
+	// Spent is charged from the start of the period through the current month.
+	Spent int `json:"spent"`
+	// Projected is what the subscriptions known now charge over the whole period.
+	Projected        int           `json:"projected"`
+	SpentPercent     int           `json:"spent_percent"`
+	ProjectedPercent int           `json:"projected_percent"`
+	Alerts           []BudgetAlert `json:"alerts"`
+}
+
+// BudgetAlert records that spend within a budget period reached a threshold.
+type BudgetAlert struct {
+	BudgetId    int       `json:"budget_id"`
+	PeriodStart YearMonth `json:"period_start" swaggertype:"string" example:"01-2025"`
+	Threshold   int       `json:"threshold" example:"80"`
+	Spent       int       `json:"spent"`
+	CreatedAt   time.Time `json:"created_at"`
+}
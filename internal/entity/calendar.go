@@ -0,0 +1,27 @@
+package entity
+
+// Kinds of calendar events.
+const (
+	CalendarRenewal = "renewal"
+	CalendarEnd     = "end"
+)
+
+// CalendarEvent is a billing date or the end of a subscription.
+type CalendarEvent struct {
+	Kind         string
+	Subscription Subscription
+	// Month is the billed month, or the last month of an ending
+	// subscription.
+	Month YearMonth
+	// Amount is what is charged in a renewal and Share the part of it the
+	// user pays.
+	Amount int
+	Share  int
+}
+
+// CalendarToken authenticates the calendar feed of a user.
+type CalendarToken struct {
+	Token string `json:"token"`
+	// URL is the path of the feed, token included.
+	URL string `json:"url" example:"/users/0b6e.../calendar.ics?token=..."`
+}
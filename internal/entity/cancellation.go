@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CancelReasonTooExpensive    = "too_expensive"
+	CancelReasonNotUsing        = "not_using"
+	CancelReasonSwitchedService = "switched_service"
+	CancelReasonTechnicalIssues = "technical_issues"
+	CancelReasonTemporary       = "temporary"
+	CancelReasonOther           = "other"
+)
+
+// CancelReasons lists the valid cancellation reason codes.
+var CancelReasons = []string{
+	CancelReasonTooExpensive,
+	CancelReasonNotUsing,
+	CancelReasonSwitchedService,
+	CancelReasonTechnicalIssues,
+	CancelReasonTemporary,
+	CancelReasonOther,
+}
+
+type CancelRequest struct {
+	// EffectiveMonth is the last billed month, by default the end of the
+	// current billing period.
+	EffectiveMonth *YearMonth `json:"effective_month" swaggertype:"string" example:"12-2025"`
+	Reason         string     `json:"reason" enums:"too_expensive,not_using,switched_service,technical_issues,temporary,other"`
+	Comment        *string    `json:"comment" example:"Moving to a family plan"`
+}
+
+// Cancellation records why and when a subscription was ended.
+type Cancellation struct {
+	Id             int       `json:"id"`
+	SubscriptionId *int      `json:"subscription_id"`
+	UserId         uuid.UUID `json:"user_id"`
+	ServiceName    string    `json:"service_name"`
+	ServiceId      *int      `json:"service_id"`
+	// Price is the monthly price when the subscription was cancelled.
+	Price     int       `json:"price"`
+	EndMonth  YearMonth `json:"end_month" swaggertype:"string" example:"12-2025"`
+	Reason    string    `json:"reason"`
+	Comment   *string   `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChurnGroup aggregates the cancellations of one service for one reason.
+type ChurnGroup struct {
+	ServiceName string `json:"service_name"`
+	Reason      string `json:"reason"`
+	Count       int    `json:"count"`
+	// LostMonthly is the sum of the monthly prices of the cancelled
+	// subscriptions.
+	LostMonthly int `json:"lost_monthly"`
+}
+
+// ChurnReport aggregates the cancellations ending from From through To.
+type ChurnReport struct {
+	From        YearMonth    `json:"from" swaggertype:"string" example:"01-2025"`
+	To          YearMonth    `json:"to" swaggertype:"string" example:"12-2025"`
+	Total       int          `json:"total"`
+	LostMonthly int          `json:"lost_monthly"`
+	Groups      []ChurnGroup `json:"groups"`
+}
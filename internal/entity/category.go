@@ -0,0 +1,5 @@
+package entity
+
+type Category struct {
+	Name string `json:"name" example:"streaming"`
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventSubscriptionCreated = "subscription.created"
+	EventSubscriptionUpdated = "subscription.updated"
+	EventSubscriptionDeleted = "subscription.deleted"
+)
+
+// EventStreamReset is sent on an event stream resumed after an event that is
+// no longer in the log: events may have been missed, so the client should
+// reload what it shows.
+const EventStreamReset = "reset"
+
+// EventTypes lists every event type, in the order they are documented.
+var EventTypes = []string{
+	EventSubscriptionCreated,
+	EventSubscriptionUpdated,
+	EventSubscriptionDeleted,
+}
+
+// Event describes a change. Events are stored in the outbox in the
+// transaction of the change, so they exist exactly when the change does.
+type Event struct {
+	Id        int64           `json:"id"`
+	Type      string          `json:"type" example:"subscription.created"`
+	Data      json.RawMessage `json:"data" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
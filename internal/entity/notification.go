@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	NotificationChannelLog     = "log"
+	NotificationChannelEmail   = "email"
+	NotificationChannelWebhook = "webhook"
+)
+
+// NotificationPreferences tell how and about what a user is notified.
+type NotificationPreferences struct {
+	UserId           uuid.UUID `json:"user_id"`
+	Channel          string    `json:"channel" enums:"log,email,webhook"`
+	WebhookURL       *string   `json:"webhook_url" example:"https://example.com/hooks/subscriptions"`
+	RenewalReminders bool      `json:"renewal_reminders"`
+	BudgetAlerts     bool      `json:"budget_alerts"`
+	// ReminderDays is how many days ahead reminders are sent; nil means the
+	// server default.
+	ReminderDays *int `json:"reminder_days" example:"3"`
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "github.com/google/uuid"
+
+// Overlap is a pair of subscriptions of the same user to the same canonical
+// service that are both active from From through To, To being nil when both
+// are open-ended. One of them is likely a duplicate.
+type Overlap struct {
+	UserId      uuid.UUID  `json:"user_id"`
+	ServiceName string     `json:"service_name"`
+	ServiceId   *int       `json:"service_id"`
+	FirstId     int        `json:"first_id"`
+	SecondId    int        `json:"second_id"`
+	From        YearMonth  `json:"from" swaggertype:"string" example:"03-2025"`
+	To          *YearMonth `json:"to" swaggertype:"string" example:"06-2025"`
+}
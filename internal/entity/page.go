@@ -0,0 +1,7 @@
+package entity
+
+// Page selects a slice of a listing. A zero Limit means no limit.
+type Page struct {
+	Limit  int
+	Offset int
+}
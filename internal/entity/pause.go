@@ -0,0 +1,41 @@
+package entity
+
+// Pause stops the billing of a subscription from From through To inclusive,
+// or until the subscription is resumed when To is nil.
+type Pause struct {
+	Id   int        `json:"id"`
+	From YearMonth  `json:"from" swaggertype:"string" example:"03-2025"`
+	To   *YearMonth `json:"to" swaggertype:"string" example:"05-2025"`
+}
+
+// Covers reports whether the month is paused.
+func (p Pause) Covers(month YearMonth) bool {
+	if month.Before(p.From) {
+		return false
+	}
+
+	return p.To == nil || !month.After(*p.To)
+}
+
+// Overlaps reports whether the pause shares a month with the one from one
+// month through another, the latter open-ended when to is nil.
+func (p Pause) Overlaps(from YearMonth, to *YearMonth) bool {
+	if to != nil && to.Before(p.From) {
+		return false
+	}
+
+	return p.To == nil || !from.After(*p.To)
+}
+
+type PauseRequest struct {
+	// From is the first paused month, the current month by default.
+	From *YearMonth `json:"from" swaggertype:"string" example:"03-2025"`
+	// To is the last paused month; without it the subscription stays paused
+	// until resumed.
+	To *YearMonth `json:"to" swaggertype:"string" example:"05-2025"`
+}
+
+type ResumeRequest struct {
+	// Month is the first month billed again, the current month by default.
+	Month *YearMonth `json:"month" swaggertype:"string" example:"06-2025"`
+}
@@ -0,0 +1,38 @@
+package entity
+
+import "github.com/google/uuid"
+
+// ServiceSpend is what was spent on a service over a period, and on how many
+// subscriptions charging something.
+type ServiceSpend struct {
+	ServiceName   string `json:"service_name"`
+	Total         int    `json:"total"`
+	Subscriptions int    `json:"subscriptions"`
+}
+
+// UserSpend is what a user spent over a period, counting their share of
+// shared subscriptions, and on how many subscriptions they paid something
+// for.
+type UserSpend struct {
+	UserId        uuid.UUID `json:"user_id"`
+	Total         int       `json:"total"`
+	Subscriptions int       `json:"subscriptions"`
+}
+
+// ServicePopularity counts the subscriptions to a service active over a
+// period.
+type ServicePopularity struct {
+	ServiceName   string `json:"service_name"`
+	Subscriptions int    `json:"subscriptions"`
+	Users         int    `json:"users"`
+}
+
+// ServicePrice describes the list prices of a service over a period,
+// averaged over the months its subscriptions were active.
+type ServicePrice struct {
+	ServiceName   string  `json:"service_name"`
+	Average       float64 `json:"average" example:"349.5"`
+	Min           int     `json:"min"`
+	Max           int     `json:"max"`
+	Subscriptions int     `json:"subscriptions"`
+}
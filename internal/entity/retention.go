@@ -0,0 +1,30 @@
+package entity
+
+// RetentionPoint is how many subscriptions of a cohort are still active a
+// number of months after the cohort started.
+type RetentionPoint struct {
+	// Offset is the number of months since the cohort month, 0 being the
+	// cohort month itself.
+	Offset int     `json:"offset"`
+	Active int     `json:"active"`
+	Rate   float64 `json:"rate" example:"0.75"`
+}
+
+// RetentionCohort is the subscriptions of a group started in the same month.
+type RetentionCohort struct {
+	// Key is the service or category of the group, empty without grouping
+	// or for subscriptions without a category.
+	Key       string           `json:"key"`
+	Cohort    YearMonth        `json:"cohort" swaggertype:"string" example:"01-2025"`
+	Size      int              `json:"size"`
+	Retention []RetentionPoint `json:"retention"`
+}
+
+// RetentionReport follows the cohorts started from From through To up to
+// the current month.
+type RetentionReport struct {
+	GroupBy GroupBy           `json:"group_by" swaggertype:"string" enums:",service,category"`
+	From    YearMonth         `json:"from" swaggertype:"string" example:"01-2025"`
+	To      YearMonth         `json:"to" swaggertype:"string" example:"12-2025"`
+	Cohorts []RetentionCohort `json:"cohorts"`
+}
@@ -0,0 +1,17 @@
+package entity
+
+// Service is a service catalog entry. Subscriptions whose service name
+// matches Name or one of Aliases, ignoring case, are linked to it.
+type Service struct {
+	Id           int      `json:"id"`
+	Name         string   `json:"name" example:"Yandex Plus"`
+	Aliases      []string `json:"aliases" example:"yandex plus,Яндекс Плюс"`
+	Category     *string  `json:"category" example:"streaming"`
+	VendorURL    *string  `json:"vendor_url" example:"https://plus.yandex.ru"`
+	DefaultPrice *int     `json:"default_price" example:"399"`
+}
+
+// Names returns the canonical name followed by the aliases.
+func (s Service) Names() []string {
+	return append([]string{s.Name}, s.Aliases...)
+}
@@ -0,0 +1,39 @@
+package entity
+
+import "github.com/google/uuid"
+
+// Split rules of a shared subscription. Whatever the shares leave of a
+// month's price is paid by the subscription's own user.
+const (
+	// SplitEqual divides the price equally between the payer and the
+	// sharing users.
+	SplitEqual = "equal"
+	// SplitPercentage gives each sharing user a percentage of the price.
+	SplitPercentage = "percentage"
+	// SplitFixed gives each sharing user a fixed amount, capped at what is
+	// left of the price.
+	SplitFixed = "fixed"
+)
+
+// Share is the part of a shared subscription a user other than the payer
+// pays. Percent is set for the percentage rule and Amount for the fixed one.
+type Share struct {
+	UserId  uuid.UUID `json:"user_id"`
+	Percent *int      `json:"percent,omitempty" example:"25"`
+	Amount  *int      `json:"amount,omitempty" example:"150"`
+}
+
+// Debt is what one user owes another for a period.
+type Debt struct {
+	From   uuid.UUID `json:"from_user_id"`
+	To     uuid.UUID `json:"to_user_id"`
+	Amount int       `json:"amount"`
+}
+
+// Settlement says who owes whom for the shared subscriptions from From
+// through To. Debts between two users are netted.
+type Settlement struct {
+	From  YearMonth `json:"from" swaggertype:"string" example:"01-2025"`
+	To    YearMonth `json:"to" swaggertype:"string" example:"03-2025"`
+	Debts []Debt    `json:"debts"`
+}
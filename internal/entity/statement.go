@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatementRequest asks for the statement of a month to be generated.
+type StatementRequest struct {
+	Month YearMonth `json:"month" swaggertype:"string" example:"06-2025"`
+}
+
+// Statement lists what a user was charged in a month. It is generated once,
+// after the month is over, and then kept as it was.
+type Statement struct {
+	UserId      uuid.UUID         `json:"user_id"`
+	DisplayName string            `json:"display_name"`
+	Currency    string            `json:"currency" example:"RUB"`
+	Month       YearMonth         `json:"month" swaggertype:"string" example:"06-2025"`
+	Charges     []StatementCharge `json:"charges"`
+	Total       int               `json:"total"`
+	// PreviousTotal is what the user was charged in the month before, as
+	// computed when the statement was generated.
+	PreviousTotal int `json:"previous_total"`
+	Change        int `json:"change"`
+	// ChangePercent is nil when nothing was charged in the month before.
+	ChangePercent *int              `json:"change_percent"`
+	Budgets       []StatementBudget `json:"budgets"`
+	GeneratedAt   time.Time         `json:"generated_at"`
+}
+
+// StatementCharge is a subscription that charged the user in the month.
+type StatementCharge struct {
+	SubscriptionId int     `json:"subscription_id"`
+	ServiceName    string  `json:"service_name"`
+	Category       *string `json:"category"`
+	// Price is what the subscription charged and Amount the part of it the
+	// user pays.
+	Price  int `json:"price"`
+	Amount int `json:"amount"`
+	// Shared is set when the subscription is split with other users.
+	Shared bool `json:"shared"`
+}
+
+// StatementBudget is the status of a budget of the user at the end of the
+// statement month.
+type StatementBudget struct {
+	BudgetId     int       `json:"budget_id"`
+	Name         string    `json:"name"`
+	Period       string    `json:"period" enums:"monthly,yearly"`
+	PeriodStart  YearMonth `json:"period_start" swaggertype:"string" example:"01-2025"`
+	PeriodEnd    YearMonth `json:"period_end" swaggertype:"string" example:"12-2025"`
+	Amount       int       `json:"amount"`
+	Spent        int       `json:"spent"`
+	SpentPercent int       `json:"spent_percent"`
+}
+
+// StatementSummary describes a stored statement without its charges.
+type StatementSummary struct {
+	Month       YearMonth `json:"month" swaggertype:"string" example:"06-2025"`
+	Total       int       `json:"total"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
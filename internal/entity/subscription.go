@@ -1,14 +1,313 @@
 package entity
 
 import (
+	"test_task/internal/expr"
+
 	"github.com/google/uuid"
 )
 
+// Subscription is active every month from StartDate through EndDate
+// inclusive, or indefinitely when EndDate is nil, and charged at the start of
+// each billing period: nothing through TrialEnd, then PromoPrice for
+// PromoMonths months, then Price as changed by PriceChanges. Paused months are
+// not charged; they do not extend the trial or the promotion, nor shift the
+// billing periods. A renewal falling in a pause is charged in the first month
+// after the pause instead, once however many renewals the pause covered.
+// UserId pays, and splits each charge with the users in Shares according to
+// SplitRule.
 type Subscription struct {
-	Id          int       `json:"id"`
-	ServiceName string    `json:"service_name"`
-	Price       int       `json:"price"`
-	UserId      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date"`
-	EndDate     *string   `json:"end_date"`
+	Id          int        `json:"id"`
+	ServiceName string     `json:"service_name"`
+	Price       int        `json:"price"`
+	UserId      uuid.UUID  `json:"user_id"`
+	StartDate   YearMonth  `json:"start_date" swaggertype:"string" example:"07-2025"`
+	EndDate     *YearMonth `json:"end_date" swaggertype:"string" example:"12-2025"`
+	// ServiceId links the subscription to the service catalog when its name
+	// matches a catalog entry; ServiceName then holds the canonical name.
+	ServiceId *int `json:"service_id"`
+	// Category defaults to the category of the linked catalog entry.
+	Category *string  `json:"category" example:"streaming"`
+	Tags     []string `json:"tags" example:"family,work"`
+	// TrialEnd is the last month of a free trial.
+	TrialEnd *YearMonth `json:"trial_end" swaggertype:"string" example:"07-2025"`
+	// PromoPrice is charged for PromoMonths months after the trial, if any.
+	PromoPrice  *int `json:"promo_price" example:"199"`
+	PromoMonths *int `json:"promo_months" example:"3"`
+	// Pauses is the pause history, oldest first. It is managed through the
+	// pause and resume endpoints and ignored on writes.
+	Pauses []Pause `json:"pauses"`
+	// SplitRule is equal, percentage or fixed; it is required with Shares.
+	SplitRule *string `json:"split_rule" enums:"equal,percentage,fixed"`
+	Shares    []Share `json:"shares"`
+	// BillingPeriod is monthly, quarterly or yearly; Price is charged once a
+	// period.
+	BillingPeriod string `json:"billing_period" enums:"monthly,quarterly,yearly" example:"monthly"`
+	// PriceChanges are the scheduled changes of Price, oldest first.
+	PriceChanges []PriceChange `json:"price_changes"`
+}
+
+// TrialConversion is a trial ending, after which the subscription is paid.
+type TrialConversion struct {
+	Subscription Subscription `json:"subscription"`
+	// ConvertsIn is the first paid month.
+	ConvertsIn YearMonth `json:"converts_in" swaggertype:"string" example:"09-2025"`
+	// FirstCharge is what is charged in that month.
+	FirstCharge int `json:"first_charge" example:"199"`
+}
+
+type SubscriptionFilter struct {
+	UserId      uuid.UUID
+	ServiceName string
+	// ServiceId, when set, takes precedence over ServiceName.
+	ServiceId *int
+	Category  string
+	Tag       string
+	From      *YearMonth
+	To        *YearMonth
+	// IncludeShared makes UserId also match the subscriptions the user
+	// shares.
+	IncludeShared bool
+	// Shared keeps only the subscriptions shared with other users.
+	Shared bool
+	// Expr is a parsed filter expression the subscriptions must also match.
+	Expr expr.Node
+}
+
+// ActiveIn reports whether the subscription is charged in the given month.
+func (s Subscription) ActiveIn(month YearMonth) bool {
+	if month.Before(s.StartDate) {
+		return false
+	}
+
+	return s.EndDate == nil || !month.After(*s.EndDate)
+}
+
+// PausedIn reports whether the given month is paused.
+func (s Subscription) PausedIn(month YearMonth) bool {
+	for _, p := range s.Pauses {
+		if p.Covers(month) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OpenPause returns the pause lasting until the subscription is resumed, if
+// any.
+func (s Subscription) OpenPause() *Pause {
+	for i := range s.Pauses {
+		if s.Pauses[i].To == nil {
+			return &s.Pauses[i]
+		}
+	}
+
+	return nil
+}
+
+// firstPaid returns the first month after the trial, if any.
+func (s Subscription) firstPaid() YearMonth {
+	if s.TrialEnd != nil {
+		return s.TrialEnd.AddMonths(1)
+	}
+
+	return s.StartDate
+}
+
+func (s Subscription) periodMonths() int {
+	n, err := BillingPeriodMonths(s.BillingPeriod)
+	if err != nil {
+		return 1
+	}
+
+	return n
+}
+
+// PeriodEnd returns the last month of the billing period containing the
+// given month, or the last month of the trial during the trial.
+func (s Subscription) PeriodEnd(month YearMonth) YearMonth {
+	first := s.firstPaid()
+	if month.Before(first) {
+		return first.AddMonths(-1)
+	}
+
+	n := s.periodMonths()
+	return month.AddMonths(n - 1 - first.MonthsUntil(month)%n)
+}
+
+// RenewsIn reports whether a billing period is charged in the given month:
+// the period starts in it or, right after a pause, started during the pause.
+func (s Subscription) RenewsIn(month YearMonth) bool {
+	_, ok := s.renewalIn(month)
+	return ok
+}
+
+// renewalIn returns the start of the billing period charged in the given
+// month. It is the month itself, or after a pause the start of the latest
+// period that began during the pause.
+func (s Subscription) renewalIn(month YearMonth) (YearMonth, bool) {
+	if !s.ActiveIn(month) || s.PausedIn(month) {
+		return YearMonth{}, false
+	}
+
+	first := s.firstPaid()
+	if month.Before(first) {
+		return YearMonth{}, false
+	}
+
+	n := s.periodMonths()
+	if first.MonthsUntil(month)%n == 0 {
+		return month, true
+	}
+
+	for m := month.AddMonths(-1); !m.Before(first) && s.PausedIn(m); m = m.AddMonths(-1) {
+		if first.MonthsUntil(m)%n == 0 {
+			return m, true
+		}
+	}
+
+	return YearMonth{}, false
+}
+
+// ListPriceIn returns the price in effect in the given month, before any
+// trial or promotion.
+func (s Subscription) ListPriceIn(month YearMonth) int {
+	price := s.Price
+	for _, c := range s.PriceChanges {
+		if !c.From.After(month) {
+			price = c.Price
+		}
+	}
+
+	return price
+}
+
+// MonthlyPriceIn returns ListPriceIn spread evenly over the months of the
+// billing period, rounded, so that prices of different periods compare.
+func (s Subscription) MonthlyPriceIn(month YearMonth) int {
+	n := s.periodMonths()
+	return (s.ListPriceIn(month) + n/2) / n
+}
+
+// PriceIn returns the amount charged in the given month. A renewal deferred
+// by a pause gets the promotion of the month it was due in and the price in
+// effect when it is charged.
+func (s Subscription) PriceIn(month YearMonth) int {
+	due, ok := s.renewalIn(month)
+	if !ok {
+		return 0
+	}
+
+	if s.PromoPrice != nil && s.PromoMonths != nil && s.firstPaid().MonthsUntil(due) < *s.PromoMonths {
+		return *s.PromoPrice
+	}
+
+	return s.ListPriceIn(month)
+}
+
+// SplitIn divides the amount charged in the given month between the payer
+// and the sharing users. Users paying nothing that month are left out.
+func (s Subscription) SplitIn(month YearMonth) map[uuid.UUID]int {
+	return s.split(s.PriceIn(month))
+}
+
+// SplitPrice divides Price between the payer and the sharing users as
+// SplitIn does a charge.
+func (s Subscription) SplitPrice() map[uuid.UUID]int {
+	return s.split(s.Price)
+}
+
+// MonthlySplitIn divides the monthly equivalent of the billing period
+// containing the given month between the users, as SplitIn does a charge,
+// so that spend in months with and without a renewal compares. The
+// promotion of the period counts; trial and paused months cost nothing.
+func (s Subscription) MonthlySplitIn(month YearMonth) map[uuid.UUID]int {
+	first := s.firstPaid()
+	if !s.ActiveIn(month) || s.PausedIn(month) || month.Before(first) {
+		return map[uuid.UUID]int{}
+	}
+
+	n := s.periodMonths()
+	start := month.AddMonths(-(first.MonthsUntil(month) % n))
+	price := s.ListPriceIn(month)
+	if s.PromoPrice != nil && s.PromoMonths != nil && first.MonthsUntil(start) < *s.PromoMonths {
+		price = *s.PromoPrice
+	}
+
+	return s.split((price + n/2) / n)
+}
+
+func (s Subscription) split(price int) map[uuid.UUID]int {
+	split := map[uuid.UUID]int{}
+	if price == 0 {
+		return split
+	}
+
+	var rule string
+	if s.SplitRule != nil {
+		rule = *s.SplitRule
+	}
+
+	rest := price
+	for _, share := range s.Shares {
+		var part int
+		switch {
+		case rule == SplitEqual:
+			part = price / (len(s.Shares) + 1)
+		case rule == SplitPercentage && share.Percent != nil:
+			part = price * *share.Percent / 100
+		case rule == SplitFixed && share.Amount != nil:
+			part = *share.Amount
+		}
+
+		part = min(part, rest)
+		if part > 0 {
+			split[share.UserId] += part
+			rest -= part
+		}
+	}
+
+	if rest > 0 {
+		split[s.UserId] += rest
+	}
+
+	return split
+}
+
+// CostBetween returns the amount charged from one month through another,
+// both inclusive.
+func (s Subscription) CostBetween(from, to YearMonth) int {
+	if from.Before(s.StartDate) {
+		from = s.StartDate
+	}
+	if s.EndDate != nil && to.After(*s.EndDate) {
+		to = *s.EndDate
+	}
+
+	var cost int
+	for month := from; !month.After(to); month = month.AddMonths(1) {
+		cost += s.PriceIn(month)
+	}
+
+	return cost
+}
+
+// SplitBetween returns what each user pays from one month through another,
+// both inclusive.
+func (s Subscription) SplitBetween(from, to YearMonth) map[uuid.UUID]int {
+	if from.Before(s.StartDate) {
+		from = s.StartDate
+	}
+	if s.EndDate != nil && to.After(*s.EndDate) {
+		to = *s.EndDate
+	}
+
+	split := map[uuid.UUID]int{}
+	for month := from; !month.After(to); month = month.AddMonths(1) {
+		for userId, part := range s.SplitIn(month) {
+			split[userId] += part
+		}
+	}
+
+	return split
 }
@@ -0,0 +1,443 @@
+package entity
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func ym(year int, month time.Month) YearMonth {
+	return NewYearMonth(year, month)
+}
+
+func ymp(year int, month time.Month) *YearMonth {
+	m := NewYearMonth(year, month)
+	return &m
+}
+
+// charges returns the amount charged in each month from one month through
+// another.
+func charges(s Subscription, from, to YearMonth) []int {
+	var out []int
+	for month := from; !month.After(to); month = month.AddMonths(1) {
+		out = append(out, s.PriceIn(month))
+	}
+
+	return out
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestPriceInBillingPeriods(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  Subscription
+		want []int // January through December 2025
+	}{
+		{
+			name: "monthly",
+			sub:  Subscription{Price: 100, StartDate: ym(2025, time.January), BillingPeriod: BillingMonthly},
+			want: []int{100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100},
+		},
+		{
+			name: "quarterly from February",
+			sub:  Subscription{Price: 300, StartDate: ym(2025, time.February), BillingPeriod: BillingQuarterly},
+			want: []int{0, 300, 0, 0, 300, 0, 0, 300, 0, 0, 300, 0},
+		},
+		{
+			name: "yearly",
+			sub:  Subscription{Price: 1200, StartDate: ym(2025, time.March), BillingPeriod: BillingYearly},
+			want: []int{0, 0, 1200, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "ended before the second period",
+			sub:  Subscription{Price: 300, StartDate: ym(2025, time.January), EndDate: ymp(2025, time.March), BillingPeriod: BillingQuarterly},
+			want: []int{300, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "price change",
+			sub: Subscription{Price: 100, StartDate: ym(2025, time.January), BillingPeriod: BillingQuarterly,
+				PriceChanges: []PriceChange{{From: ym(2025, time.May), Price: 150}}},
+			want: []int{100, 0, 0, 100, 0, 0, 150, 0, 0, 150, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := charges(tt.sub, ym(2025, time.January), ym(2025, time.December))
+			if !equalInts(got, tt.want) {
+				t.Errorf("charges = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceInPauses(t *testing.T) {
+	tests := []struct {
+		name   string
+		period string
+		pauses []Pause
+		want   []int // January 2025 through March 2026
+	}{
+		{
+			name:   "monthly pause skips the paused months",
+			period: BillingMonthly,
+			pauses: []Pause{{From: ym(2025, time.March), To: ymp(2025, time.April)}},
+			want:   []int{100, 100, 0, 0, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100},
+		},
+		{
+			name:   "yearly renewal in a pause moves after it",
+			period: BillingYearly,
+			pauses: []Pause{{From: ym(2025, time.December), To: ymp(2026, time.January)}},
+			want:   []int{100, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 100, 0},
+		},
+		{
+			name:   "first yearly charge in a pause moves after it",
+			period: BillingYearly,
+			pauses: []Pause{{From: ym(2025, time.January), To: ymp(2025, time.February)}},
+			want:   []int{0, 0, 100, 0, 0, 0, 0, 0, 0, 0, 0, 0, 100, 0, 0},
+		},
+		{
+			name:   "quarterly pause without a renewal changes nothing",
+			period: BillingQuarterly,
+			pauses: []Pause{{From: ym(2025, time.February), To: ymp(2025, time.March)}},
+			want:   []int{100, 0, 0, 100, 0, 0, 100, 0, 0, 100, 0, 0, 100, 0, 0},
+		},
+		{
+			name:   "quarterly periods do not shift after a deferred renewal",
+			period: BillingQuarterly,
+			pauses: []Pause{{From: ym(2025, time.April), To: ymp(2025, time.May)}},
+			want:   []int{100, 0, 0, 0, 0, 100, 100, 0, 0, 100, 0, 0, 100, 0, 0},
+		},
+		{
+			name:   "renewals covered by one pause are charged once",
+			period: BillingQuarterly,
+			pauses: []Pause{{From: ym(2025, time.March), To: ymp(2025, time.August)}},
+			want:   []int{100, 0, 0, 0, 0, 0, 0, 0, 100, 100, 0, 0, 100, 0, 0},
+		},
+		{
+			name:   "adjacent pauses act as one",
+			period: BillingYearly,
+			pauses: []Pause{
+				{From: ym(2025, time.December), To: ymp(2025, time.December)},
+				{From: ym(2026, time.January), To: ymp(2026, time.February)},
+			},
+			want: []int{100, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 100},
+		},
+		{
+			name:   "open pause",
+			period: BillingYearly,
+			pauses: []Pause{{From: ym(2025, time.June)}},
+			want:   []int{100, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := Subscription{Price: 100, StartDate: ym(2025, time.January), BillingPeriod: tt.period, Pauses: tt.pauses}
+			got := charges(sub, ym(2025, time.January), ym(2026, time.March))
+			if !equalInts(got, tt.want) {
+				t.Errorf("charges = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceInDeferredRenewalAfterEnd(t *testing.T) {
+	sub := Subscription{
+		Price:         100,
+		StartDate:     ym(2025, time.January),
+		EndDate:       ymp(2025, time.February),
+		BillingPeriod: BillingMonthly,
+		Pauses:        []Pause{{From: ym(2025, time.February), To: ymp(2025, time.March)}},
+	}
+
+	if got := sub.CostBetween(ym(2025, time.January), ym(2025, time.December)); got != 100 {
+		t.Errorf("CostBetween = %d, want 100", got)
+	}
+}
+
+func TestRenewsIn(t *testing.T) {
+	sub := Subscription{
+		StartDate:     ym(2025, time.January),
+		TrialEnd:      ymp(2025, time.February),
+		BillingPeriod: BillingQuarterly,
+		Pauses:        []Pause{{From: ym(2025, time.May), To: ymp(2025, time.June)}},
+	}
+
+	tests := []struct {
+		month YearMonth
+		want  bool
+	}{
+		{ym(2024, time.December), false},
+		{ym(2025, time.January), false},
+		{ym(2025, time.February), false},
+		{ym(2025, time.March), true},
+		{ym(2025, time.April), false},
+		{ym(2025, time.June), false},
+		{ym(2025, time.July), true},
+		{ym(2025, time.August), false},
+		{ym(2025, time.September), true},
+		{ym(2025, time.December), true},
+	}
+
+	for _, tt := range tests {
+		if got := sub.RenewsIn(tt.month); got != tt.want {
+			t.Errorf("RenewsIn(%s) = %v, want %v", tt.month, got, tt.want)
+		}
+	}
+}
+
+func TestPeriodEnd(t *testing.T) {
+	sub := Subscription{StartDate: ym(2025, time.February), TrialEnd: ymp(2025, time.March), BillingPeriod: BillingQuarterly}
+
+	tests := []struct {
+		month, want YearMonth
+	}{
+		{ym(2025, time.February), ym(2025, time.March)},
+		{ym(2025, time.April), ym(2025, time.June)},
+		{ym(2025, time.June), ym(2025, time.June)},
+		{ym(2025, time.July), ym(2025, time.September)},
+	}
+
+	for _, tt := range tests {
+		if got := sub.PeriodEnd(tt.month); got != tt.want {
+			t.Errorf("PeriodEnd(%s) = %s, want %s", tt.month, got, tt.want)
+		}
+	}
+}
+
+func TestMonthlyPriceIn(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  Subscription
+		want int
+	}{
+		{"monthly", Subscription{Price: 100, BillingPeriod: BillingMonthly}, 100},
+		{"quarterly", Subscription{Price: 300, BillingPeriod: BillingQuarterly}, 100},
+		{"yearly rounded", Subscription{Price: 1000, BillingPeriod: BillingYearly}, 83},
+		{
+			name: "price change",
+			sub: Subscription{Price: 1200, BillingPeriod: BillingYearly, PriceChanges: []PriceChange{
+				{From: ym(2025, time.March), Price: 2400},
+			}},
+			want: 200,
+		},
+	}
+
+	for _, tt := range tests {
+		tt.sub.StartDate = ym(2025, time.January)
+		if got := tt.sub.MonthlyPriceIn(ym(2025, time.June)); got != tt.want {
+			t.Errorf("%s: MonthlyPriceIn = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPriceInTrialsAndPromos(t *testing.T) {
+	intp := func(v int) *int { return &v }
+
+	tests := []struct {
+		name string
+		sub  Subscription
+		want []int // January through August 2025
+	}{
+		{
+			name: "trial",
+			sub:  Subscription{Price: 100, TrialEnd: ymp(2025, time.February), BillingPeriod: BillingMonthly},
+			want: []int{0, 0, 100, 100, 100, 100, 100, 100},
+		},
+		{
+			name: "promo after trial",
+			sub: Subscription{Price: 100, TrialEnd: ymp(2025, time.February), BillingPeriod: BillingMonthly,
+				PromoPrice: intp(50), PromoMonths: intp(2)},
+			want: []int{0, 0, 50, 50, 100, 100, 100, 100},
+		},
+		{
+			name: "promo without trial",
+			sub:  Subscription{Price: 100, BillingPeriod: BillingMonthly, PromoPrice: intp(50), PromoMonths: intp(3)},
+			want: []int{50, 50, 50, 100, 100, 100, 100, 100},
+		},
+		{
+			name: "promo months counted from the first paid month",
+			sub:  Subscription{Price: 300, BillingPeriod: BillingQuarterly, PromoPrice: intp(150), PromoMonths: intp(3)},
+			want: []int{150, 0, 0, 300, 0, 0, 300, 0},
+		},
+		{
+			name: "deferred renewal keeps the promo it was due with",
+			sub: Subscription{Price: 300, BillingPeriod: BillingQuarterly, PromoPrice: intp(150), PromoMonths: intp(4),
+				Pauses: []Pause{{From: ym(2025, time.March), To: ymp(2025, time.April)}}},
+			want: []int{150, 0, 0, 0, 150, 0, 300, 0},
+		},
+		{
+			name: "promo ends with the price change in effect",
+			sub: Subscription{Price: 100, BillingPeriod: BillingMonthly, PromoPrice: intp(50), PromoMonths: intp(2),
+				PriceChanges: []PriceChange{{From: ym(2025, time.February), Price: 120}}},
+			want: []int{50, 50, 120, 120, 120, 120, 120, 120},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.sub.StartDate = ym(2025, time.January)
+		got := charges(tt.sub, ym(2025, time.January), ym(2025, time.August))
+		if !equalInts(got, tt.want) {
+			t.Errorf("%s: charges = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSplitIn(t *testing.T) {
+	intp := func(v int) *int { return &v }
+	rule := func(r string) *string { return &r }
+	payer := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	first := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	second := uuid.MustParse("00000000-0000-0000-0000-000000000003")
+
+	tests := []struct {
+		name  string
+		sub   Subscription
+		month YearMonth
+		want  map[uuid.UUID]int
+	}{
+		{
+			name:  "not shared",
+			sub:   Subscription{Price: 100},
+			month: ym(2025, time.March),
+			want:  map[uuid.UUID]int{payer: 100},
+		},
+		{
+			name: "equal, remainder to the payer",
+			sub: Subscription{Price: 100, SplitRule: rule(SplitEqual),
+				Shares: []Share{{UserId: first}, {UserId: second}}},
+			month: ym(2025, time.March),
+			want:  map[uuid.UUID]int{payer: 34, first: 33, second: 33},
+		},
+		{
+			name: "percentage",
+			sub: Subscription{Price: 200, SplitRule: rule(SplitPercentage),
+				Shares: []Share{{UserId: first, Percent: intp(25)}, {UserId: second, Percent: intp(50)}}},
+			month: ym(2025, time.March),
+			want:  map[uuid.UUID]int{payer: 50, first: 50, second: 100},
+		},
+		{
+			name: "fixed amounts capped by the price",
+			sub: Subscription{Price: 100, SplitRule: rule(SplitFixed),
+				Shares: []Share{{UserId: first, Amount: intp(30)}, {UserId: second, Amount: intp(90)}}},
+			month: ym(2025, time.March),
+			want:  map[uuid.UUID]int{first: 30, second: 70},
+		},
+		{
+			name: "share without a part left out",
+			sub: Subscription{Price: 100, SplitRule: rule(SplitPercentage),
+				Shares: []Share{{UserId: first, Percent: intp(40)}, {UserId: second}}},
+			month: ym(2025, time.March),
+			want:  map[uuid.UUID]int{payer: 60, first: 40},
+		},
+		{
+			name: "promotion split",
+			sub: Subscription{Price: 100, PromoPrice: intp(60), PromoMonths: intp(1), SplitRule: rule(SplitEqual),
+				Shares: []Share{{UserId: first}}},
+			month: ym(2025, time.January),
+			want:  map[uuid.UUID]int{payer: 30, first: 30},
+		},
+		{
+			name: "month without a charge",
+			sub: Subscription{Price: 300, BillingPeriod: BillingQuarterly, SplitRule: rule(SplitEqual),
+				Shares: []Share{{UserId: first}}},
+			month: ym(2025, time.February),
+			want:  map[uuid.UUID]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.sub.UserId = payer
+		tt.sub.StartDate = ym(2025, time.January)
+		if tt.sub.BillingPeriod == "" {
+			tt.sub.BillingPeriod = BillingMonthly
+		}
+
+		if got := tt.sub.SplitIn(tt.month); !maps.Equal(got, tt.want) {
+			t.Errorf("%s: SplitIn = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMonthlySplitIn(t *testing.T) {
+	intp := func(v int) *int { return &v }
+	rule := func(r string) *string { return &r }
+	payer := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	first := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+
+	tests := []struct {
+		name  string
+		sub   Subscription
+		month YearMonth
+		want  map[uuid.UUID]int
+	}{
+		{
+			name:  "quarterly, renewal month",
+			sub:   Subscription{Price: 300, BillingPeriod: BillingQuarterly},
+			month: ym(2025, time.April),
+			want:  map[uuid.UUID]int{payer: 100},
+		},
+		{
+			name:  "quarterly, month without a charge",
+			sub:   Subscription{Price: 300, BillingPeriod: BillingQuarterly},
+			month: ym(2025, time.May),
+			want:  map[uuid.UUID]int{payer: 100},
+		},
+		{
+			name: "yearly shared",
+			sub: Subscription{Price: 1200, BillingPeriod: BillingYearly, SplitRule: rule(SplitEqual),
+				Shares: []Share{{UserId: first}}},
+			month: ym(2025, time.August),
+			want:  map[uuid.UUID]int{payer: 50, first: 50},
+		},
+		{
+			name:  "promotion of the period",
+			sub:   Subscription{Price: 300, BillingPeriod: BillingQuarterly, PromoPrice: intp(150), PromoMonths: intp(3)},
+			month: ym(2025, time.March),
+			want:  map[uuid.UUID]int{payer: 50},
+		},
+		{
+			name:  "after the promotion",
+			sub:   Subscription{Price: 300, BillingPeriod: BillingQuarterly, PromoPrice: intp(150), PromoMonths: intp(3)},
+			month: ym(2025, time.April),
+			want:  map[uuid.UUID]int{payer: 100},
+		},
+		{
+			name:  "trial",
+			sub:   Subscription{Price: 300, BillingPeriod: BillingQuarterly, TrialEnd: ymp(2025, time.February)},
+			month: ym(2025, time.February),
+			want:  map[uuid.UUID]int{},
+		},
+		{
+			name: "paused",
+			sub: Subscription{Price: 300, BillingPeriod: BillingQuarterly,
+				Pauses: []Pause{{From: ym(2025, time.February), To: ymp(2025, time.February)}}},
+			month: ym(2025, time.February),
+			want:  map[uuid.UUID]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.sub.UserId = payer
+		tt.sub.StartDate = ym(2025, time.January)
+
+		if got := tt.sub.MonthlySplitIn(tt.month); !maps.Equal(got, tt.want) {
+			t.Errorf("%s: MonthlySplitIn = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
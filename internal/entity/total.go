@@ -0,0 +1,90 @@
+package entity
+
+import "fmt"
+
+// GroupBy selects how /subscriptions/total breaks the total down.
+type GroupBy string
+
+// Subscriptions without a category or tags fall into a group with an empty
+// key. A subscription with several tags counts towards each of them, so tag
+// groups can add up to more than the total.
+const (
+	GroupByNone     GroupBy = ""
+	GroupByCategory GroupBy = "category"
+	GroupByTag      GroupBy = "tag"
+	GroupByService  GroupBy = "service"
+	GroupByUser     GroupBy = "user"
+)
+
+func ParseGroupBy(s string) (GroupBy, error) {
+	switch g := GroupBy(s); g {
+	case GroupByNone, GroupByCategory, GroupByTag, GroupByService, GroupByUser:
+		return g, nil
+	default:
+		return "", fmt.Errorf("unknown group_by %q: expected category, tag, service or user", s)
+	}
+}
+
+// CostBasis selects what /subscriptions/total adds up.
+type CostBasis string
+
+const (
+	// CostBasisPrice adds the price of each subscription starting on or
+	// after the first month and ending by the last one once, as the endpoint
+	// always did.
+	CostBasisPrice CostBasis = "price"
+	// CostBasisCharges adds what the subscriptions charge each month of the
+	// period.
+	CostBasisCharges CostBasis = "charges"
+)
+
+// ParseCostBasis parses a cost basis, CostBasisPrice when empty.
+func ParseCostBasis(s string) (CostBasis, error) {
+	switch b := CostBasis(s); b {
+	case "":
+		return CostBasisPrice, nil
+	case CostBasisPrice, CostBasisCharges:
+		return b, nil
+	default:
+		return "", fmt.Errorf("unknown basis %q: expected price or charges", s)
+	}
+}
+
+type CostGroup struct {
+	Key   string `json:"key"`
+	Total int    `json:"total"`
+}
+
+// GroupKeys returns the groups a subscription belongs to.
+func (g GroupBy) GroupKeys(sub Subscription) []string {
+	switch g {
+	case GroupByCategory:
+		if sub.Category == nil {
+			return []string{""}
+		}
+		return []string{*sub.Category}
+	case GroupByTag:
+		if len(sub.Tags) == 0 {
+			return []string{""}
+		}
+		return sub.Tags
+	case GroupByService:
+		return []string{sub.ServiceName}
+	case GroupByUser:
+		return []string{sub.UserId.String()}
+	default:
+		return nil
+	}
+}
+
+// TotalCost is the total of subscriptions from From through To on a basis.
+// With CostBasisCharges it covers From (or the start of each subscription
+// when nil) through To, which is always set; with CostBasisPrice a nil bound
+// is not checked.
+type TotalCost struct {
+	Basis  CostBasis   `json:"basis" enums:"price,charges"`
+	From   *YearMonth  `json:"from" swaggertype:"string" example:"01-2025"`
+	To     *YearMonth  `json:"to" swaggertype:"string" example:"12-2025"`
+	Total  int         `json:"total"`
+	Groups []CostGroup `json:"groups,omitempty"`
+}
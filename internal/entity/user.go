@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	UserStatusActive   = "active"
+	UserStatusInactive = "inactive"
+)
+
+type User struct {
+	Id              uuid.UUID `json:"id"`
+	DisplayName     string    `json:"display_name"`
+	Email           *string   `json:"email"`
+	Timezone        string    `json:"timezone" example:"Europe/Moscow"`
+	DefaultCurrency string    `json:"default_currency" example:"RUB"`
+	Status          string    `json:"status" enums:"active,inactive"`
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"time"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Webhook is an integrator endpoint receiving events. The secret signs the
+// deliveries and is only returned when the webhook is created.
+type Webhook struct {
+	Id         int       `json:"id"`
+	URL        string    `json:"url" example:"https://example.com/hooks"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"event_types" example:"subscription.created,subscription.deleted"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is the delivery of one event to one webhook.
+type WebhookDelivery struct {
+	Id             int64      `json:"id"`
+	WebhookId      int        `json:"webhook_id"`
+	EventId        int64      `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status" enums:"pending,succeeded,failed"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus *int       `json:"response_status"`
+	LastError      *string    `json:"last_error"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// PendingDelivery is a delivery due for an attempt, with what it takes to
+// make one.
+type PendingDelivery struct {
+	Delivery WebhookDelivery
+	Webhook  Webhook
+	Event    Event
+}
@@ -0,0 +1,180 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// YearMonth is a calendar month without a day, the granularity in which
+// subscriptions are billed. The zero value means "not set".
+type YearMonth struct {
+	Year  int
+	Month time.Month
+}
+
+func NewYearMonth(year int, month time.Month) YearMonth {
+	return YearMonthOf(time.Date(year, month, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func YearMonthOf(t time.Time) YearMonth {
+	return YearMonth{Year: t.Year(), Month: t.Month()}
+}
+
+func CurrentYearMonth() YearMonth {
+	return YearMonthOf(time.Now())
+}
+
+// yearMonthLayouts lists the accepted input formats. The day in YYYY-MM-DD is
+// validated and then dropped.
+var yearMonthLayouts = []struct {
+	pattern *regexp.Regexp
+	layout  string
+}{
+	{regexp.MustCompile(`^\d{2}-\d{4}$`), "01-2006"},
+	{regexp.MustCompile(`^\d{4}-\d{2}$`), "2006-01"},
+	{regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`), "2006-01-02"},
+	{regexp.MustCompile(`^\d{2}/\d{4}$`), "01/2006"},
+}
+
+// ParseYearMonth parses a month in MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY
+// format.
+func ParseYearMonth(s string) (YearMonth, error) {
+	for _, l := range yearMonthLayouts {
+		if !l.pattern.MatchString(s) {
+			continue
+		}
+
+		t, err := time.Parse(l.layout, s)
+		if err != nil {
+			return YearMonth{}, fmt.Errorf("invalid date %q: no such month or day", s)
+		}
+
+		return YearMonthOf(t), nil
+	}
+
+	return YearMonth{}, fmt.Errorf("invalid date %q: expected MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY", s)
+}
+
+func (ym YearMonth) IsZero() bool {
+	return ym == YearMonth{}
+}
+
+// String formats the month as MM-YYYY.
+func (ym YearMonth) String() string {
+	return ym.Format(DateFormatMonthYear)
+}
+
+func (ym YearMonth) Format(f DateFormat) string {
+	if f == DateFormatISO {
+		return fmt.Sprintf("%04d-%02d", ym.Year, int(ym.Month))
+	}
+
+	return fmt.Sprintf("%02d-%04d", int(ym.Month), ym.Year)
+}
+
+// DateFormat selects how months are written in responses.
+type DateFormat int
+
+const (
+	// DateFormatMonthYear is MM-YYYY, the default.
+	DateFormatMonthYear DateFormat = iota
+	// DateFormatISO is the ISO 8601 YYYY-MM.
+	DateFormatISO
+)
+
+func ParseDateFormat(s string) (DateFormat, error) {
+	switch strings.ToLower(s) {
+	case "", "mm-yyyy":
+		return DateFormatMonthYear, nil
+	case "iso", "yyyy-mm":
+		return DateFormatISO, nil
+	default:
+		return 0, fmt.Errorf("unknown date format %q: expected mm-yyyy or iso", s)
+	}
+}
+
+// Time returns the first day of the month at midnight UTC.
+func (ym YearMonth) Time() time.Time {
+	return time.Date(ym.Year, ym.Month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (ym YearMonth) AddMonths(n int) YearMonth {
+	return YearMonthOf(ym.Time().AddDate(0, n, 0))
+}
+
+func (ym YearMonth) Before(other YearMonth) bool {
+	return ym.index() < other.index()
+}
+
+func (ym YearMonth) After(other YearMonth) bool {
+	return ym.index() > other.index()
+}
+
+// MonthsUntil returns the number of months from ym to other, negative if
+// other is earlier.
+func (ym YearMonth) MonthsUntil(other YearMonth) int {
+	return other.index() - ym.index()
+}
+
+func (ym YearMonth) index() int {
+	return ym.Year*12 + int(ym.Month) - 1
+}
+
+func (ym YearMonth) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ym.String())
+}
+
+func (ym *YearMonth) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	parsed, err := ParseYearMonth(s)
+	if err != nil {
+		return err
+	}
+
+	*ym = parsed
+	return nil
+}
+
+// Scan reads a DATE column; the day of month is ignored.
+func (ym *YearMonth) Scan(src any) error {
+	switch v := src.(type) {
+	case time.Time:
+		*ym = YearMonthOf(v)
+		return nil
+	case []byte:
+		return ym.Scan(string(v))
+	case string:
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return fmt.Errorf("cannot scan %q into YearMonth", v)
+		}
+		*ym = YearMonthOf(t)
+		return nil
+	case nil:
+		*ym = YearMonth{}
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into YearMonth", src)
+	}
+}
+
+// Value stores the month as the first day of the month.
+func (ym YearMonth) Value() (driver.Value, error) {
+	if ym.IsZero() {
+		return nil, nil
+	}
+
+	return ym.Time(), nil
+}
@@ -0,0 +1,154 @@
+// Package expr parses the filter expressions accepted by the subscription
+// listings, such as
+//
+//	price > 500 and service_name ~ "yandex*" and active_in("01-2025")
+//
+// into a type-checked syntax tree. Translating the tree into SQL is left to
+// the repository, which binds every literal as a query parameter.
+//
+// An expression combines conditions with and, or, not and parentheses; and
+// binds tighter than or. A condition is either a comparison of a field with a
+// literal or a function call:
+//
+//   - id, price: numbers, compared with = != < <= > >=. price is the list
+//     price in effect this month.
+//   - service_name, category, billing_period: strings, compared with = and !=
+//     or matched with ~ and !~ against a case-insensitive pattern where *
+//     stands for any characters and ? for one.
+//   - user_id: a UUID string, compared with = and !=.
+//   - start_date, end_date: months written as strings, "MM-YYYY" or
+//     "YYYY-MM", compared with = != < <= > >=.
+//   - category and end_date can also be compared with null.
+//   - active_in("MM-YYYY") holds for subscriptions running in the month,
+//     has_tag("tag") for subscriptions with the tag and shared() for
+//     subscriptions shared with other users.
+package expr
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxLength bounds the length of an expression, in characters.
+const MaxLength = 1000
+
+// maxDepth bounds the nesting of an expression.
+const maxDepth = 32
+
+// Node is a node of a parsed expression: *And, *Or, *Not, *Comparison or
+// *Call.
+type Node interface {
+	node()
+}
+
+type And struct {
+	Left, Right Node
+}
+
+type Or struct {
+	Left, Right Node
+}
+
+type Not struct {
+	X Node
+}
+
+// Comparison compares a field with a literal. Value is an int for number
+// fields, a string for string fields, a uuid.UUID for user_id, the first day
+// of the month as a time.Time for month fields and nil for null.
+type Comparison struct {
+	Field string
+	Op    string
+	Value any
+}
+
+// Call is a function call. Args hold values as in Comparison.
+type Call struct {
+	Func string
+	Args []any
+}
+
+func (*And) node()        {}
+func (*Or) node()         {}
+func (*Not) node()        {}
+func (*Comparison) node() {}
+func (*Call) node()       {}
+
+// Error is a syntax or type error in an expression.
+type Error struct {
+	// Pos is the 1-based position of the offending character or token.
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+func errorf(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+type valueType int
+
+const (
+	typeNumber valueType = iota
+	typeString
+	typeUUID
+	typeMonth
+)
+
+func (t valueType) String() string {
+	switch t {
+	case typeNumber:
+		return "a number"
+	case typeUUID:
+		return "a UUID string"
+	case typeMonth:
+		return `a month string such as "01-2025"`
+	default:
+		return "a string"
+	}
+}
+
+type field struct {
+	typ      valueType
+	nullable bool
+}
+
+var fields = map[string]field{
+	"id":             {typ: typeNumber},
+	"price":          {typ: typeNumber},
+	"service_name":   {typ: typeString},
+	"category":       {typ: typeString, nullable: true},
+	"billing_period": {typ: typeString},
+	"user_id":        {typ: typeUUID},
+	"start_date":     {typ: typeMonth},
+	"end_date":       {typ: typeMonth, nullable: true},
+}
+
+var operators = map[valueType][]string{
+	typeNumber: {"=", "!=", "<", "<=", ">", ">="},
+	typeString: {"=", "!=", "~", "!~"},
+	typeUUID:   {"=", "!="},
+	typeMonth:  {"=", "!=", "<", "<=", ">", ">="},
+}
+
+var functions = map[string][]valueType{
+	"active_in": {typeMonth},
+	"has_tag":   {typeString},
+	"shared":    {},
+}
+
+// monthLayouts are the accepted month formats.
+var monthLayouts = []string{"01-2006", "2006-01"}
+
+func parseMonth(s string) (time.Time, bool) {
+	for _, layout := range monthLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,113 @@
+package expr
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	// text is the token as written, or the unquoted value of a string.
+	text string
+	// pos is the 1-based position of the first character of the token.
+	pos int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return `"` + t.text + `"`
+	default:
+		return t.text
+	}
+}
+
+// lex splits a filter into tokens, ending with a tokenEOF.
+func lex(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: pos})
+			i++
+		case r == '"':
+			var b strings.Builder
+			i++
+			for {
+				if i == len(runes) {
+					return nil, errorf(pos, "unterminated string")
+				}
+				if runes[i] == '"' {
+					i++
+					break
+				}
+				if runes[i] == '\\' {
+					if i+1 == len(runes) || (runes[i+1] != '"' && runes[i+1] != '\\') {
+						return nil, errorf(i+1, `invalid escape: only \" and \\ are allowed`)
+					}
+					i++
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: b.String(), pos: pos})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: pos})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: pos})
+		case strings.ContainsRune("=!<>~", r):
+			op := string(r)
+			if i+1 < len(runes) {
+				if two := op + string(runes[i+1]); two == "!=" || two == "<=" || two == ">=" || two == "!~" {
+					op = two
+				}
+			}
+			if op == "!" {
+				return nil, errorf(pos, `unexpected "!": expected != or !~`)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: pos})
+			i += len(op)
+		default:
+			return nil, errorf(pos, "unexpected character %q", r)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
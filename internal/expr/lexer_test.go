@@ -0,0 +1,86 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []token
+	}{
+		{
+			input: `price >= 500`,
+			want: []token{
+				{kind: tokenIdent, text: "price", pos: 1},
+				{kind: tokenOp, text: ">=", pos: 7},
+				{kind: tokenNumber, text: "500", pos: 10},
+				{kind: tokenEOF, pos: 13},
+			},
+		},
+		{
+			input: `has_tag("a \"b\" \\")`,
+			want: []token{
+				{kind: tokenIdent, text: "has_tag", pos: 1},
+				{kind: tokenLParen, text: "(", pos: 8},
+				{kind: tokenString, text: `a "b" \`, pos: 9},
+				{kind: tokenRParen, text: ")", pos: 21},
+				{kind: tokenEOF, pos: 22},
+			},
+		},
+		{
+			input: `id!=-3,x!~"é"`,
+			want: []token{
+				{kind: tokenIdent, text: "id", pos: 1},
+				{kind: tokenOp, text: "!=", pos: 3},
+				{kind: tokenNumber, text: "-3", pos: 5},
+				{kind: tokenComma, text: ",", pos: 7},
+				{kind: tokenIdent, text: "x", pos: 8},
+				{kind: tokenOp, text: "!~", pos: 9},
+				{kind: tokenString, text: "é", pos: 11},
+				{kind: tokenEOF, pos: 14},
+			},
+		},
+		{
+			input: "  ",
+			want:  []token{{kind: tokenEOF, pos: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := lex(tt.input)
+		if err != nil {
+			t.Errorf("lex(%q): %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lex(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLexErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{`service_name = "netflix`, 16, "unterminated string"},
+		{`service_name = "a\n"`, 18, `invalid escape: only \" and \\ are allowed`},
+		{`price ! 5`, 7, `unexpected "!": expected != or !~`},
+		{`price > 5 & id = 1`, 11, `unexpected character '&'`},
+	}
+
+	for _, tt := range tests {
+		_, err := lex(tt.input)
+		e, ok := err.(*Error)
+		if !ok {
+			t.Errorf("lex(%q) error = %v, want an *Error", tt.input, err)
+			continue
+		}
+		if e.Pos != tt.pos || e.Msg != tt.msg {
+			t.Errorf("lex(%q) error = %d %q, want %d %q", tt.input, e.Pos, e.Msg, tt.pos, tt.msg)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package expr
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Parse parses and type-checks a filter expression. Errors are *Error values
+// pointing at the offending token.
+func Parse(input string) (Node, error) {
+	if n := len([]rune(input)); n > MaxLength {
+		return nil, errorf(MaxLength+1, "filter is longer than %d characters", MaxLength)
+	}
+
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, errorf(p.peek().pos, "filter is empty")
+	}
+
+	node, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, errorf(t.pos, "unexpected %s: expected and, or or end of filter", t)
+	}
+
+	return node, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) keyword(word string) bool {
+	t := p.peek()
+	if t.kind == tokenIdent && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, errorf(t.pos, "unexpected %s: expected %s", t, what)
+	}
+
+	return t, nil
+}
+
+func (p *parser) parseOr(depth int) (Node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("or") {
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd(depth int) (Node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("and") {
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary(depth int) (Node, error) {
+	if depth >= maxDepth {
+		return nil, errorf(p.peek().pos, "filter is nested deeper than %d levels", maxDepth)
+	}
+
+	if p.keyword("not") {
+		x, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{X: x}, nil
+	}
+
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		node, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, ")"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case tokenIdent:
+		if p.peek().kind == tokenLParen {
+			return p.parseCall(t)
+		}
+		return p.parseComparison(t)
+	default:
+		return nil, errorf(t.pos, "unexpected %s: expected a field, a function, not or (", t)
+	}
+}
+
+func (p *parser) parseComparison(name token) (Node, error) {
+	f, ok := fields[name.text]
+	if !ok {
+		return nil, errorf(name.pos, "unknown field %q", name.text)
+	}
+
+	op, err := p.expect(tokenOp, "a comparison operator")
+	if err != nil {
+		return nil, err
+	}
+
+	if p.keyword("null") {
+		if !f.nullable {
+			return nil, errorf(p.tokens[p.pos-1].pos, "%s cannot be null", name.text)
+		}
+		if op.text != "=" && op.text != "!=" {
+			return nil, errorf(op.pos, "null can only be compared with = or !=")
+		}
+		return &Comparison{Field: name.text, Op: op.text, Value: nil}, nil
+	}
+
+	if !slices.Contains(operators[f.typ], op.text) {
+		return nil, errorf(op.pos, "operator %s cannot be used with %s; use one of %s",
+			op.text, name.text, strings.Join(operators[f.typ], " "))
+	}
+
+	value, err := p.parseValue(f.typ)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Comparison{Field: name.text, Op: op.text, Value: value}, nil
+}
+
+func (p *parser) parseCall(name token) (Node, error) {
+	params, ok := functions[name.text]
+	if !ok {
+		return nil, errorf(name.pos, "unknown function %q", name.text)
+	}
+	p.next()
+
+	call := &Call{Func: name.text, Args: []any{}}
+	for i, typ := range params {
+		if i > 0 {
+			if _, err := p.expect(tokenComma, ","); err != nil {
+				return nil, err
+			}
+		}
+
+		value, err := p.parseValue(typ)
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, value)
+	}
+
+	if t, err := p.expect(tokenRParen, ")"); err != nil {
+		if t.kind == tokenComma || (len(params) == 0 && t.kind != tokenEOF) {
+			return nil, errorf(t.pos, "too many arguments to %s", name.text)
+		}
+		return nil, err
+	}
+
+	return call, nil
+}
+
+func (p *parser) parseValue(typ valueType) (any, error) {
+	t := p.next()
+
+	switch {
+	case typ == typeNumber && t.kind == tokenNumber:
+		n, err := strconv.Atoi(t.text)
+		if err != nil {
+			return nil, errorf(t.pos, "number %s is out of range", t.text)
+		}
+		return n, nil
+	case typ == typeString && t.kind == tokenString:
+		return t.text, nil
+	case typ == typeUUID && t.kind == tokenString:
+		id, err := uuid.Parse(t.text)
+		if err != nil {
+			return nil, errorf(t.pos, "invalid UUID %s", t)
+		}
+		return id, nil
+	case typ == typeMonth && t.kind == tokenString:
+		month, ok := parseMonth(t.text)
+		if !ok {
+			return nil, errorf(t.pos, `invalid month %s: expected "MM-YYYY" or "YYYY-MM"`, t)
+		}
+		return month, nil
+	default:
+		return nil, errorf(t.pos, "unexpected %s: expected %s", t, typ)
+	}
+}
@@ -0,0 +1,108 @@
+package expr
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParse(t *testing.T) {
+	january := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	user := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+
+	tests := []struct {
+		input string
+		want  Node
+	}{
+		{
+			input: `price > 500`,
+			want:  &Comparison{Field: "price", Op: ">", Value: 500},
+		},
+		{
+			input: `price > 500 and service_name ~ "yandex*" or shared()`,
+			want: &Or{
+				Left: &And{
+					Left:  &Comparison{Field: "price", Op: ">", Value: 500},
+					Right: &Comparison{Field: "service_name", Op: "~", Value: "yandex*"},
+				},
+				Right: &Call{Func: "shared", Args: []any{}},
+			},
+		},
+		{
+			input: `not (category = null OR end_date != null) AND active_in("2025-01")`,
+			want: &And{
+				Left: &Not{X: &Or{
+					Left:  &Comparison{Field: "category", Op: "=", Value: nil},
+					Right: &Comparison{Field: "end_date", Op: "!=", Value: nil},
+				}},
+				Right: &Call{Func: "active_in", Args: []any{january}},
+			},
+		},
+		{
+			input: `user_id = "60601fee-2bf1-4721-ae6f-7636e79a0cba" and start_date <= "01-2025"`,
+			want: &And{
+				Left:  &Comparison{Field: "user_id", Op: "=", Value: user},
+				Right: &Comparison{Field: "start_date", Op: "<=", Value: january},
+			},
+		},
+		{
+			input: `has_tag("work") and id != -1`,
+			want: &And{
+				Left:  &Call{Func: "has_tag", Args: []any{"work"}},
+				Right: &Comparison{Field: "id", Op: "!=", Value: -1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{``, 1, "filter is empty"},
+		{`cost > 5`, 1, `unknown field "cost"`},
+		{`price ~ 5`, 7, "operator ~ cannot be used with price; use one of = != < <= > >="},
+		{`price > "5"`, 9, `unexpected "5": expected a number`},
+		{`price = null`, 9, "price cannot be null"},
+		{`end_date < null`, 10, "null can only be compared with = or !="},
+		{`user_id = "nope"`, 11, `invalid UUID "nope"`},
+		{`start_date = "2025/01"`, 14, `invalid month "2025/01": expected "MM-YYYY" or "YYYY-MM"`},
+		{`price > 5 price < 9`, 11, "unexpected price: expected and, or or end of filter"},
+		{`(price > 5`, 11, "unexpected end of filter: expected )"},
+		{`and price > 5`, 1, `unknown field "and"`},
+		{`shared(1)`, 8, "too many arguments to shared"},
+		{`has_tag("a", "b")`, 12, "too many arguments to has_tag"},
+		{`renews_in("01-2025")`, 1, `unknown function "renews_in"`},
+		{`price > 99999999999999999999`, 9, "number 99999999999999999999 is out of range"},
+		{strings.Repeat("(", maxDepth) + "shared()" + strings.Repeat(")", maxDepth), maxDepth + 1, "filter is nested deeper than 32 levels"},
+		{strings.Repeat(" ", MaxLength) + "x", MaxLength + 1, "filter is longer than 1000 characters"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		e, ok := err.(*Error)
+		if !ok {
+			t.Errorf("Parse(%q) error = %v, want an *Error", tt.input, err)
+			continue
+		}
+		if e.Pos != tt.pos || e.Msg != tt.msg {
+			t.Errorf("Parse(%q) error = %d %q, want %d %q", tt.input, e.Pos, e.Msg, tt.pos, tt.msg)
+		}
+	}
+}
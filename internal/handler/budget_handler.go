@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/entity"
+	"test_task/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type BudgetHandler struct {
+	service *service.BudgetService
+}
+
+func NewBudgetHandler(service *service.BudgetService) *BudgetHandler {
+	return &BudgetHandler{
+		service: service,
+	}
+}
+
+// CreateBudgetHandler godoc
+//
+// @Summary Create a budget
+// @Description A budget limits the monthly or yearly spend of a user, a category or a service; scope fields left empty match everything
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param budget body entity.Budget true "Budget"
+// @Success 201 {object} entity.Budget "Created budget"
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /budgets [post]
+func (h *BudgetHandler) CreateBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request entity.Budget
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	budget, err := h.service.CreateBudget(ctx, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные данные бюджета", "error", err)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка создания бюджета", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(budget); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetAllBudgetsHandler godoc
+// @Summary Get budgets
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param user_id query string false "Only budgets of this user" Format(uuid)
+// @Success 200 {array} entity.Budget "Budgets"
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /budgets [get]
+func (h *BudgetHandler) GetAllBudgetsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var userId uuid.UUID
+	if raw := r.URL.Query().Get("user_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			http.Error(w, "invalid user_id", http.StatusBadRequest)
+			slog.Error("ошибка парсинга user_id", "error", err)
+			return
+		}
+		userId = id
+	}
+
+	budgets, err := h.service.GetBudgets(ctx, userId)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения бюджетов", "error", err)
+		return
+	}
+
+	if budgets == nil {
+		budgets = []entity.Budget{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(budgets); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetBudgetHandler godoc
+// @Summary Get a budget by id
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Success 200 {object} entity.Budget "Budget"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /budgets/{id} [get]
+func (h *BudgetHandler) GetBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid budget id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	budget, err := h.service.GetBudgetById(ctx, id)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Budget not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения бюджета", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(budget); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// UpdateBudgetHandler godoc
+// @Summary Update a budget by id
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Param budget body entity.Budget true "Budget"
+// @Success 204
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /budgets/{id} [put]
+func (h *BudgetHandler) UpdateBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid budget id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	var request entity.Budget
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	request.Id = id
+
+	err = h.service.UpdateBudget(ctx, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные данные бюджета", "error", err)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Budget not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка обновления бюджета", "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteBudgetHandler godoc
+// @Summary Delete a budget by id
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Success 204
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /budgets/{id} [delete]
+func (h *BudgetHandler) DeleteBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid budget id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	err = h.service.DeleteBudget(ctx, id)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Budget not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка удаления бюджета", "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBudgetStatusHandler godoc
+// @Summary Get the spend against a budget
+// @Description Spend of the current budget period so far and projected to its end, with the alerts raised in the period. A background job raises an alert once per period when the spend reaches 80% and 100% of the budget.
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Success 200 {object} entity.BudgetStatus "Budget status"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /budgets/{id}/status [get]
+func (h *BudgetHandler) GetBudgetStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid budget id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	status, err := h.service.GetBudgetStatus(ctx, id)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Budget not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка расчета бюджета", "error", err)
+		return
+	}
+
+	if status.Alerts == nil {
+		status.Alerts = []entity.BudgetAlert{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
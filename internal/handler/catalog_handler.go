@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/entity"
+	"test_task/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+type CatalogHandler struct {
+	service *service.CatalogService
+}
+
+func NewCatalogHandler(service *service.CatalogService) *CatalogHandler {
+	return &CatalogHandler{
+		service: service,
+	}
+}
+
+// CreateServiceHandler godoc
+//
+// @Summary Add a service to the catalog
+// @Description Existing subscriptions named like the service or one of its aliases are linked to it, take its category when they have none and publish subscription.updated events
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param service body entity.Service true "Catalog entry"
+// @Success 201 {object} entity.Service "Created catalog entry"
+// @Failure 400 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /services [post]
+func (h *CatalogHandler) CreateServiceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request entity.Service
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	svc, err := h.service.CreateService(ctx, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные данные сервиса", "error", err)
+		return
+	}
+
+	if errors.Is(err, service.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		slog.Info("Конфликт при создании сервиса", "error", err)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка создания сервиса", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(svc); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetAllServicesHandler godoc
+// @Summary Get the service catalog
+// @Tags services
+// @Accept json
+// @Produce json
+// @Success 200 {array} entity.Service "Catalog entries"
+// @Failure 500 {string} string
+// @Router /services [get]
+func (h *CatalogHandler) GetAllServicesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	services, err := h.service.GetAllServices(ctx)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения каталога", "error", err)
+		return
+	}
+
+	if services == nil {
+		services = []entity.Service{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(services); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetServiceHandler godoc
+// @Summary Get a catalog entry by id
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path int true "Service ID"
+// @Success 200 {object} entity.Service "Catalog entry"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /services/{id} [get]
+func (h *CatalogHandler) GetServiceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid service id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	svc, err := h.service.GetServiceById(ctx, id)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения сервиса", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(svc); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// UpdateServiceHandler godoc
+// @Summary Update a catalog entry by id
+// @Description Linked subscriptions are renamed to the new canonical name, subscriptions without a category take the service category, and each changed subscription publishes a subscription.updated event
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path int true "Service ID"
+// @Param service body entity.Service true "Catalog entry"
+// @Success 204
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /services/{id} [put]
+func (h *CatalogHandler) UpdateServiceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid service id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	var request entity.Service
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	request.Id = id
+
+	err = h.service.UpdateService(ctx, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные данные сервиса", "error", err)
+		return
+	}
+
+	if errors.Is(err, service.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		slog.Info("Конфликт при обновлении сервиса", "error", err)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка обновления сервиса", "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteServiceHandler godoc
+// @Summary Delete a catalog entry by id
+// @Description Linked subscriptions keep their names and are unlinked
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path int true "Service ID"
+// @Success 204
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /services/{id} [delete]
+func (h *CatalogHandler) DeleteServiceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid service id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	err = h.service.DeleteService(ctx, id)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка удаления сервиса", "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"test_task/internal/entity"
+	"test_task/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+type CategoryHandler struct {
+	service *service.CategoryService
+}
+
+func NewCategoryHandler(service *service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		service: service,
+	}
+}
+
+// CreateCategoryHandler godoc
+//
+// @Summary Create a category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param category body entity.Category true "Category"
+// @Success 201 {object} entity.Category "Created category"
+// @Failure 400 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /categories [post]
+func (h *CategoryHandler) CreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request entity.Category
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	category, err := h.service.CreateCategory(ctx, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректная категория", "error", err)
+		return
+	}
+
+	if errors.Is(err, service.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка создания категории", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(category); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetAllCategoriesHandler godoc
+// @Summary Get all categories
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Success 200 {array} entity.Category "Categories"
+// @Failure 500 {string} string
+// @Router /categories [get]
+func (h *CategoryHandler) GetAllCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	categories, err := h.service.GetAllCategories(ctx)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения категорий", "error", err)
+		return
+	}
+
+	if categories == nil {
+		categories = []entity.Category{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(categories); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// DeleteCategoryHandler godoc
+// @Summary Delete an unused category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param name path string true "Category name"
+// @Success 204
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /categories/{name} [delete]
+func (h *CategoryHandler) DeleteCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := h.service.DeleteCategory(ctx, mux.Vars(r)["name"])
+	if errors.Is(err, service.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка удаления категории", "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/service"
+	"time"
+)
+
+const eventsHeartbeat = 15 * time.Second
+
+type EventHandler struct {
+	service *service.EventService
+}
+
+func NewEventHandler(service *service.EventService) *EventHandler {
+	return &EventHandler{
+		service: service,
+	}
+}
+
+// StreamEventsHandler godoc
+// @Summary Stream subscription changes
+// @Description Server-Sent Events stream of subscription.created, subscription.updated and subscription.deleted events; the data of an event is the subscription. A client reconnecting with Last-Event-ID first gets the events it missed that are still in the event log; a "reset" event tells it that some may be lost.
+// @Tags subscriptions
+// @Produce text/event-stream
+// @Param user_id query string false "Only events of this user" Format(uuid)
+// @Param service_name query string false "Only events of this service or catalog alias"
+// @Param Last-Event-ID header int false "Id of the last event received"
+// @Success 200 {string} string "Event stream"
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /subscriptions/events [get]
+func (h *EventHandler) StreamEventsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := subscriptionFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+
+	var lastEventId int64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		lastEventId, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			slog.Error("ошибка парсинга Last-Event-ID", "error", err)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		slog.Error("ResponseWriter не поддерживает Flush")
+		return
+	}
+
+	events, err := h.service.Subscribe(ctx, filter, lastEventId)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка подписки на события", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if e.Id != 0 {
+				fmt.Fprintf(w, "id: %d\n", e.Id)
+			}
+			fmt.Fprintf(w, "event: %s\n", e.Type)
+			data := e.Data
+			if data == nil {
+				data = []byte("{}")
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"test_task/internal/database"
+)
+
+// ReadYourWritesHeader, set to true, sends the reads of a request to the
+// primary database so a client sees the writes it has just made even when
+// read replicas lag behind.
+const ReadYourWritesHeader = "X-Read-Your-Writes"
+
+func ReadYourWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if usePrimary, _ := strconv.ParseBool(r.Header.Get(ReadYourWritesHeader)); usePrimary {
+			r = r.WithContext(database.WithPrimary(r.Context()))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"test_task/internal/entity"
+	"test_task/internal/service"
+)
+
+type NotificationHandler struct {
+	service *service.NotificationService
+}
+
+func NewNotificationHandler(service *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		service: service,
+	}
+}
+
+// GetPreferencesHandler godoc
+// @Summary Get notification preferences of a user
+// @Description Users that have not set any get the server defaults
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" Format(uuid)
+// @Success 200 {object} entity.NotificationPreferences "Notification preferences"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /users/{id}/notification-preferences [get]
+func (h *NotificationHandler) GetPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.service.GetPreferences(ctx, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения настроек уведомлений", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(prefs); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// SetPreferencesHandler godoc
+// @Summary Set notification preferences of a user
+// @Description Email notifications go to the email of the user; channels that cannot reach the user fall back to the server default. A webhook_url has to resolve to public addresses only.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" Format(uuid)
+// @Param preferences body entity.NotificationPreferences true "Notification preferences"
+// @Success 204
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /users/{id}/notification-preferences [put]
+func (h *NotificationHandler) SetPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var request entity.NotificationPreferences
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	request.UserId = id
+
+	err := h.service.SetPreferences(ctx, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные настройки уведомлений", "error", err)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка сохранения настроек уведомлений", "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
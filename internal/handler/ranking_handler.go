@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/entity"
+	"test_task/internal/service"
+)
+
+const defaultRankingLimit = 10
+
+// GetTopServicesHandler godoc
+// @Summary Top services by spend
+// @Description Rank services by what the matching subscriptions charged over a period, counted as by /subscriptions/total. With user_id, only the user's share of shared subscriptions counts.
+// @Tags reports
+// @Produce json
+// @Param limit query int false "Number of services, 1 to 100 (default 10)"
+// @Param user_id query string false "Filter by user ID" Format(uuid)
+// @Param service_name query string false "Filter by service name or catalog alias"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param from_date query string false "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY"
+// @Param to_date query string false "Last month of the period, same formats (defaults to the current month)"
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {array} entity.ServiceSpend
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /reports/top-services [get]
+func (h *SubscriptionHandler) GetTopServicesHandler(w http.ResponseWriter, r *http.Request) {
+	writeRanking(w, r, h.service.GetTopServicesBySpend)
+}
+
+// GetTopUsersHandler godoc
+// @Summary Top users by spend
+// @Description Rank users by what they paid for the matching subscriptions over a period, counting their share of shared subscriptions.
+// @Tags reports
+// @Produce json
+// @Param limit query int false "Number of users, 1 to 100 (default 10)"
+// @Param user_id query string false "Filter by user ID" Format(uuid)
+// @Param service_name query string false "Filter by service name or catalog alias"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param from_date query string false "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY"
+// @Param to_date query string false "Last month of the period, same formats (defaults to the current month)"
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {array} entity.UserSpend
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /reports/top-users [get]
+func (h *SubscriptionHandler) GetTopUsersHandler(w http.ResponseWriter, r *http.Request) {
+	writeRanking(w, r, h.service.GetTopUsersBySpend)
+}
+
+// GetCommonServicesHandler godoc
+// @Summary Most common services
+// @Description Rank services by how many matching subscriptions are active at least one month of a period.
+// @Tags reports
+// @Produce json
+// @Param limit query int false "Number of services, 1 to 100 (default 10)"
+// @Param user_id query string false "Filter by user ID" Format(uuid)
+// @Param service_name query string false "Filter by service name or catalog alias"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param from_date query string false "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY"
+// @Param to_date query string false "Last month of the period, same formats (defaults to the current month)"
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {array} entity.ServicePopularity
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /reports/common-services [get]
+func (h *SubscriptionHandler) GetCommonServicesHandler(w http.ResponseWriter, r *http.Request) {
+	writeRanking(w, r, h.service.GetMostCommonServices)
+}
+
+// GetAveragePricesHandler godoc
+// @Summary Average price per service
+// @Description Average, lowest and highest list price of each service over the months the matching subscriptions were active in a period, scheduled price changes included and trials and promotions left out; most expensive first.
+// @Tags reports
+// @Produce json
+// @Param limit query int false "Number of services, 1 to 100 (default 10)"
+// @Param user_id query string false "Filter by user ID" Format(uuid)
+// @Param service_name query string false "Filter by service name or catalog alias"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param from_date query string false "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY"
+// @Param to_date query string false "Last month of the period, same formats (defaults to the current month)"
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {array} entity.ServicePrice
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /reports/average-prices [get]
+func (h *SubscriptionHandler) GetAveragePricesHandler(w http.ResponseWriter, r *http.Request) {
+	writeRanking(w, r, h.service.GetAveragePrices)
+}
+
+// GetRetentionHandler godoc
+// @Summary Retention of subscription cohorts
+// @Description Group the matching subscriptions by start month (cohort), optionally per service or category, and report for each month since the start, up to the current month, how many of them and which fraction are still active according to their start and end dates.
+// @Tags reports
+// @Produce json
+// @Param group_by query string false "Follow the cohorts of each service or category separately" Enums(service, category)
+// @Param months query int false "Number of months to follow each cohort for, 1 to 120 (default 12)"
+// @Param user_id query string false "Filter by user ID" Format(uuid)
+// @Param service_name query string false "Filter by service name or catalog alias"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param from_date query string false "First cohort month: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY (defaults to eleven months before to_date)"
+// @Param to_date query string false "Last cohort month, same formats (defaults to the current month)"
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {object} entity.RetentionReport
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /reports/retention [get]
+func (h *SubscriptionHandler) GetRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	filter, err := subscriptionFilterFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+
+	groupBy, err := entity.ParseGroupBy(query.Get("group_by"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга группировки", "error", err)
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	months := 12
+	if v := query.Get("months"); v != "" {
+		months, err = strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid months", http.StatusBadRequest)
+			slog.Error("ошибка парсинга months", "error", err)
+			return
+		}
+	}
+
+	report, err := h.service.GetRetention(ctx, filter, groupBy, months)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные параметры отчёта", "error", err)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка построения отчёта об удержании", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(newRetentionReportResponse(*report, format)); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// writeRanking answers a ranking report request from the subscription
+// filters and the limit query parameter.
+func writeRanking[T any](w http.ResponseWriter, r *http.Request, rank func(context.Context, entity.SubscriptionFilter, int) ([]T, error)) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	filter, err := subscriptionFilterFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+
+	limit := defaultRankingLimit
+	if v := query.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			slog.Error("ошибка парсинга limit", "error", err)
+			return
+		}
+	}
+
+	ranking, err := rank(ctx, filter, limit)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные параметры отчёта", "error", err)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка построения отчёта", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(ranking); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
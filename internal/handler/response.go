@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+	"test_task/internal/entity"
+)
+
+// subscriptionResponse renders a subscription with its months in the date
+// format the client asked for.
+type subscriptionResponse struct {
+	entity.Subscription
+	StartDate    string                `json:"start_date"`
+	EndDate      *string               `json:"end_date"`
+	TrialEnd     *string               `json:"trial_end"`
+	Pauses       []pauseResponse       `json:"pauses"`
+	PriceChanges []priceChangeResponse `json:"price_changes"`
+}
+
+type pauseResponse struct {
+	Id   int     `json:"id"`
+	From string  `json:"from"`
+	To   *string `json:"to"`
+}
+
+type priceChangeResponse struct {
+	From  string `json:"from"`
+	Price int    `json:"price"`
+}
+
+func newSubscriptionResponse(sub entity.Subscription, format entity.DateFormat) subscriptionResponse {
+	resp := subscriptionResponse{
+		Subscription: sub,
+		StartDate:    sub.StartDate.Format(format),
+		Pauses:       make([]pauseResponse, 0, len(sub.Pauses)),
+		PriceChanges: make([]priceChangeResponse, 0, len(sub.PriceChanges)),
+	}
+
+	if sub.EndDate != nil {
+		endDate := sub.EndDate.Format(format)
+		resp.EndDate = &endDate
+	}
+
+	if sub.TrialEnd != nil {
+		trialEnd := sub.TrialEnd.Format(format)
+		resp.TrialEnd = &trialEnd
+	}
+
+	for _, p := range sub.Pauses {
+		pause := pauseResponse{Id: p.Id, From: p.From.Format(format)}
+		if p.To != nil {
+			to := p.To.Format(format)
+			pause.To = &to
+		}
+		resp.Pauses = append(resp.Pauses, pause)
+	}
+
+	for _, c := range sub.PriceChanges {
+		resp.PriceChanges = append(resp.PriceChanges, priceChangeResponse{From: c.From.Format(format), Price: c.Price})
+	}
+
+	return resp
+}
+
+func newSubscriptionResponses(subs []entity.Subscription, format entity.DateFormat) []subscriptionResponse {
+	resp := make([]subscriptionResponse, 0, len(subs))
+	for _, sub := range subs {
+		resp = append(resp, newSubscriptionResponse(sub, format))
+	}
+
+	return resp
+}
+
+type trialConversionResponse struct {
+	Subscription subscriptionResponse `json:"subscription"`
+	ConvertsIn   string               `json:"converts_in"`
+	FirstCharge  int                  `json:"first_charge"`
+}
+
+func newTrialConversionResponses(conversions []entity.TrialConversion, format entity.DateFormat) []trialConversionResponse {
+	resp := make([]trialConversionResponse, 0, len(conversions))
+	for _, c := range conversions {
+		resp = append(resp, trialConversionResponse{
+			Subscription: newSubscriptionResponse(c.Subscription, format),
+			ConvertsIn:   c.ConvertsIn.Format(format),
+			FirstCharge:  c.FirstCharge,
+		})
+	}
+
+	return resp
+}
+
+type churnReportResponse struct {
+	entity.ChurnReport
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func newChurnReportResponse(report entity.ChurnReport, format entity.DateFormat) churnReportResponse {
+	return churnReportResponse{
+		ChurnReport: report,
+		From:        report.From.Format(format),
+		To:          report.To.Format(format),
+	}
+}
+
+type settlementResponse struct {
+	entity.Settlement
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func newSettlementResponse(settlement entity.Settlement, format entity.DateFormat) settlementResponse {
+	return settlementResponse{
+		Settlement: settlement,
+		From:       settlement.From.Format(format),
+		To:         settlement.To.Format(format),
+	}
+}
+
+type forecastResponse struct {
+	entity.Forecast
+	From   string                  `json:"from"`
+	To     string                  `json:"to"`
+	Months []forecastPointResponse `json:"months"`
+}
+
+type forecastPointResponse struct {
+	entity.ForecastPoint
+	Month string `json:"month"`
+}
+
+func newForecastResponse(forecast entity.Forecast, format entity.DateFormat) forecastResponse {
+	resp := forecastResponse{
+		Forecast: forecast,
+		From:     forecast.From.Format(format),
+		To:       forecast.To.Format(format),
+		Months:   make([]forecastPointResponse, 0, len(forecast.Months)),
+	}
+
+	for _, point := range forecast.Months {
+		resp.Months = append(resp.Months, forecastPointResponse{
+			ForecastPoint: point,
+			Month:         point.Month.Format(format),
+		})
+	}
+
+	return resp
+}
+
+type totalCostResponse struct {
+	entity.TotalCost
+	From *string `json:"from"`
+	To   *string `json:"to"`
+}
+
+func newTotalCostResponse(total entity.TotalCost, format entity.DateFormat) totalCostResponse {
+	resp := totalCostResponse{TotalCost: total}
+
+	if total.From != nil {
+		from := total.From.Format(format)
+		resp.From = &from
+	}
+
+	if total.To != nil {
+		to := total.To.Format(format)
+		resp.To = &to
+	}
+
+	return resp
+}
+
+type overlapResponse struct {
+	entity.Overlap
+	From string  `json:"from"`
+	To   *string `json:"to"`
+}
+
+func newOverlapResponses(overlaps []entity.Overlap, format entity.DateFormat) []overlapResponse {
+	resp := make([]overlapResponse, 0, len(overlaps))
+	for _, o := range overlaps {
+		item := overlapResponse{Overlap: o, From: o.From.Format(format)}
+		if o.To != nil {
+			to := o.To.Format(format)
+			item.To = &to
+		}
+		resp = append(resp, item)
+	}
+
+	return resp
+}
+
+type anomalyReportResponse struct {
+	entity.AnomalyReport
+	Month string `json:"month"`
+}
+
+type retentionReportResponse struct {
+	entity.RetentionReport
+	From    string                    `json:"from"`
+	To      string                    `json:"to"`
+	Cohorts []retentionCohortResponse `json:"cohorts"`
+}
+
+type retentionCohortResponse struct {
+	entity.RetentionCohort
+	Cohort string `json:"cohort"`
+}
+
+func newRetentionReportResponse(report entity.RetentionReport, format entity.DateFormat) retentionReportResponse {
+	resp := retentionReportResponse{
+		RetentionReport: report,
+		From:            report.From.Format(format),
+		To:              report.To.Format(format),
+		Cohorts:         make([]retentionCohortResponse, 0, len(report.Cohorts)),
+	}
+
+	for _, c := range report.Cohorts {
+		resp.Cohorts = append(resp.Cohorts, retentionCohortResponse{
+			RetentionCohort: c,
+			Cohort:          c.Cohort.Format(format),
+		})
+	}
+
+	return resp
+}
+
+type statementResponse struct {
+	entity.Statement
+	Month   string                    `json:"month"`
+	Budgets []statementBudgetResponse `json:"budgets"`
+}
+
+type statementBudgetResponse struct {
+	entity.StatementBudget
+	PeriodStart string `json:"period_start"`
+	PeriodEnd   string `json:"period_end"`
+}
+
+func newStatementResponse(st entity.Statement, format entity.DateFormat) statementResponse {
+	resp := statementResponse{
+		Statement: st,
+		Month:     st.Month.Format(format),
+		Budgets:   make([]statementBudgetResponse, 0, len(st.Budgets)),
+	}
+
+	for _, b := range st.Budgets {
+		resp.Budgets = append(resp.Budgets, statementBudgetResponse{
+			StatementBudget: b,
+			PeriodStart:     b.PeriodStart.Format(format),
+			PeriodEnd:       b.PeriodEnd.Format(format),
+		})
+	}
+
+	return resp
+}
+
+type statementSummaryResponse struct {
+	entity.StatementSummary
+	Month string `json:"month"`
+}
+
+func newStatementSummaryResponses(statements []entity.StatementSummary, format entity.DateFormat) []statementSummaryResponse {
+	resp := make([]statementSummaryResponse, 0, len(statements))
+	for _, st := range statements {
+		resp = append(resp, statementSummaryResponse{
+			StatementSummary: st,
+			Month:            st.Month.Format(format),
+		})
+	}
+
+	return resp
+}
+
+// dateFormatFromRequest reads the response date format from the date_format
+// query parameter or, failing that, from a date-format parameter of the
+// Accept header, e.g. "application/json; date-format=iso".
+func dateFormatFromRequest(r *http.Request) (entity.DateFormat, error) {
+	if format := r.URL.Query().Get("date_format"); format != "" {
+		return entity.ParseDateFormat(format)
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		_, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+
+		if format, ok := params["date-format"]; ok {
+			return entity.ParseDateFormat(format)
+		}
+	}
+
+	return entity.DateFormatMonthYear, nil
+}
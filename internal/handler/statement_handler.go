@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"test_task/internal/entity"
+	"test_task/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+type StatementHandler struct {
+	service *service.StatementService
+}
+
+func NewStatementHandler(service *service.StatementService) *StatementHandler {
+	return &StatementHandler{
+		service: service,
+	}
+}
+
+// GenerateStatementHandler godoc
+//
+// @Summary Generate a monthly statement
+// @Description Build and store the statement of a user for a past month: every charge of the subscriptions they pay or share, the total, the change from the month before and the status of their budgets. A statement is generated once and never changes afterwards, even when subscriptions are edited. The scheduler stores the statements of active users as soon as a month is over; a statement generated here reflects the subscriptions and budgets as they are now, including edits made since the month closed.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" Format(uuid)
+// @Param request body entity.StatementRequest true "Statement month"
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Success 201 {object} entity.Statement
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /users/{id}/statements [post]
+func (h *StatementHandler) GenerateStatementHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	var request entity.StatementRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	st, err := h.service.GenerateStatement(ctx, id, request.Month)
+	if err == sql.ErrNoRows {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if errors.Is(err, service.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка формирования выписки", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(newStatementResponse(*st, format)); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetStatementsHandler godoc
+// @Summary List the statements of a user
+// @Description Generated statements of a user without their charges, latest month first
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID" Format(uuid)
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Success 200 {array} entity.StatementSummary
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /users/{id}/statements [get]
+func (h *StatementHandler) GetStatementsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	statements, err := h.service.GetStatements(ctx, id)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения выписок", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(newStatementSummaryResponses(statements, format)); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetStatementHandler godoc
+// @Summary Get a monthly statement
+// @Description Stored statement of a user for a month, as JSON, an HTML page or a PDF document
+// @Tags users
+// @Produce json
+// @Produce html
+// @Produce application/pdf
+// @Param id path string true "User ID" Format(uuid)
+// @Param month path string true "Statement month, MM-YYYY or YYYY-MM" example(06-2025)
+// @Param format query string false "Document format (default json)" Enums(json, html, pdf)
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Success 200 {object} entity.Statement
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /users/{id}/statements/{month} [get]
+func (h *StatementHandler) GetStatementHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	month, err := entity.ParseYearMonth(mux.Vars(r)["month"])
+	if err != nil {
+		http.Error(w, "invalid month", http.StatusBadRequest)
+		slog.Error("ошибка парсинга month", "error", err)
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	document := r.URL.Query().Get("format")
+	switch document {
+	case "", "json", "html", "pdf":
+	default:
+		http.Error(w, "format should be json, html or pdf", http.StatusBadRequest)
+		return
+	}
+
+	st, err := h.service.GetStatement(ctx, id, month)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Statement not found", http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения выписки", "error", err)
+		return
+	}
+
+	// The document is rendered in full before anything is written, so a
+	// rendering failure can still be answered with 500.
+	var buf bytes.Buffer
+	var contentType string
+	switch document {
+	case "html":
+		contentType = "text/html; charset=utf-8"
+		err = writeStatementHTML(&buf, *st, format)
+	case "pdf":
+		contentType = "application/pdf"
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="statement-%s.pdf"`, st.Month.Format(entity.DateFormatISO)))
+		err = writeStatementPDF(&buf, *st, format)
+	default:
+		contentType = "application/json"
+		err = json.NewEncoder(&buf).Encode(newStatementResponse(*st, format))
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка отображения выписки", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		slog.Error("Ошибка записи выписки", "error", err)
+	}
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"test_task/internal/entity"
+	"test_task/internal/pdf"
+)
+
+var statementTemplate = template.Must(template.New("statement").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Statement {{.Month}}: {{.DisplayName}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border-bottom: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+td.amount, th.amount { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Statement for {{.Month}}</h1>
+<p>{{.DisplayName}}, generated {{.GeneratedAt}}. Amounts in {{.Currency}}.</p>
+<h2>Charges</h2>
+{{if .Charges}}<table>
+<tr><th>Service</th><th>Category</th><th>Subscription</th><th class="amount">Price</th><th class="amount">Your part</th></tr>
+{{range .Charges}}<tr><td>{{.ServiceName}}</td><td>{{with .Category}}{{.}}{{end}}</td><td>{{.SubscriptionId}}{{if .Shared}} (shared){{end}}</td><td class="amount">{{.Price}}</td><td class="amount">{{.Amount}}</td></tr>
+{{end}}<tr><th colspan="4">Total</th><th class="amount">{{.Total}}</th></tr>
+</table>{{else}}<p>Nothing was charged this month.</p>{{end}}
+<p>Previous month: {{.PreviousTotal}}. Change: {{.Change}}{{with .ChangePercent}} ({{.}}%){{end}}.</p>
+{{if .Budgets}}<h2>Budgets</h2>
+<table>
+<tr><th>Budget</th><th>Period</th><th class="amount">Amount</th><th class="amount">Spent</th><th class="amount">Spent, %</th></tr>
+{{range .Budgets}}<tr><td>{{.Name}}</td><td>{{.PeriodStart}} to {{.PeriodEnd}}</td><td class="amount">{{.Amount}}</td><td class="amount">{{.Spent}}</td><td class="amount">{{.SpentPercent}}</td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`))
+
+func writeStatementHTML(w io.Writer, st entity.Statement, format entity.DateFormat) error {
+	return statementTemplate.Execute(w, newStatementDocument(st, format))
+}
+
+func writeStatementPDF(w io.Writer, st entity.Statement, format entity.DateFormat) error {
+	doc := newStatementDocument(st, format)
+
+	lines := []pdf.Line{
+		pdf.Text(18, true, "Statement for "+doc.Month),
+		pdf.Text(10, false, fmt.Sprintf("%s, generated %s. Amounts in %s.", doc.DisplayName, doc.GeneratedAt, doc.Currency)),
+		pdf.Text(10, false, ""),
+		pdf.Text(13, true, "Charges"),
+	}
+
+	if len(doc.Charges) == 0 {
+		lines = append(lines, pdf.Text(10, false, "Nothing was charged this month."))
+	} else {
+		lines = append(lines, chargeLine(true, "Service", "Category", "Subscription", "Price", "Your part"))
+		for _, c := range doc.Charges {
+			var category string
+			if c.Category != nil {
+				category = *c.Category
+			}
+
+			id := fmt.Sprint(c.SubscriptionId)
+			if c.Shared {
+				id += " (shared)"
+			}
+
+			lines = append(lines, chargeLine(false, c.ServiceName, category, id, fmt.Sprint(c.Price), fmt.Sprint(c.Amount)))
+		}
+		lines = append(lines, chargeLine(true, "Total", "", "", "", fmt.Sprint(doc.Total)))
+	}
+
+	change := fmt.Sprintf("Previous month: %d. Change: %d", doc.PreviousTotal, doc.Change)
+	if doc.ChangePercent != nil {
+		change += fmt.Sprintf(" (%d%%)", *doc.ChangePercent)
+	}
+	lines = append(lines, pdf.Text(10, false, ""), pdf.Text(10, false, change+"."))
+
+	if len(doc.Budgets) > 0 {
+		lines = append(lines,
+			pdf.Text(10, false, ""),
+			pdf.Text(13, true, "Budgets"),
+			chargeLine(true, "Budget", "Period", "Amount", "Spent", "Spent, %"),
+		)
+		for _, b := range doc.Budgets {
+			lines = append(lines, chargeLine(false, b.Name, b.PeriodStart+" to "+b.PeriodEnd,
+				fmt.Sprint(b.Amount), fmt.Sprint(b.Spent), fmt.Sprint(b.SpentPercent)))
+		}
+	}
+
+	return pdf.Write(w, "Statement "+doc.Month, lines)
+}
+
+// chargeLine lays out a table row of the PDF statement.
+func chargeLine(bold bool, cells ...string) pdf.Line {
+	columns := []float64{0, 160, 270, 370, 430}
+
+	line := pdf.Line{Size: 10, Bold: bold}
+	for i, text := range cells {
+		line.Cells = append(line.Cells, pdf.Cell{X: columns[i], Text: text})
+	}
+
+	return line
+}
+
+// statementDocument is a statement with dates formatted for display.
+type statementDocument struct {
+	statementResponse
+	GeneratedAt string
+}
+
+func newStatementDocument(st entity.Statement, format entity.DateFormat) statementDocument {
+	return statementDocument{
+		statementResponse: newStatementResponse(st, format),
+		GeneratedAt:       st.GeneratedAt.Format("2006-01-02 15:04 MST"),
+	}
+}
@@ -3,10 +3,16 @@ package handler
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"test_task/internal/entity"
+	"test_task/internal/expr"
 	"test_task/internal/service"
 
 	"github.com/google/uuid"
@@ -26,12 +32,15 @@ func NewSubscriptionHandler(service *service.SubscriptionService) *SubscriptionH
 // CreateSubHandler godoc
 //
 // @Summary Create a new subscription
+// @Description Depending on OVERLAP_POLICY, a subscription sharing months with another one of the same user to the same service is rejected with 409, or stored with the ids of the overlapping subscriptions in the X-Overlapping-Subscriptions header.
 // @Tags subscriptions
 // @Accept json
 // @Produce application/json
 // @Param subscription body entity.Subscription true "Subscription data"
 // @Success 201
+// @Header 201 {string} X-Overlapping-Subscriptions "Ids of the overlapping subscriptions"
 // @Failure 400 {string} string
+// @Failure 409 {string} string
 // @Failure 500 {string} string
 // @Router /subscriptions [post]
 func (h *SubscriptionHandler) CreateSubHandler(w http.ResponseWriter, r *http.Request) {
@@ -40,19 +49,32 @@ func (h *SubscriptionHandler) CreateSubHandler(w http.ResponseWriter, r *http.Re
 	var request entity.Subscription
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
 		slog.Error("Неправильный JSON", "error", err)
 		return
 	}
 	defer r.Body.Close()
 
-	_, err := h.service.CreateSubscription(ctx, request)
+	_, overlaps, err := h.service.CreateSubscription(ctx, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные данные подписки", "error", err)
+		return
+	}
+
+	if errors.Is(err, service.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		slog.Info("Подписка пересекается с существующей", "error", err)
+		return
+	}
+
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		slog.Error("Ошибка создания подписки", "error", err)
 		return
 	}
 
+	setOverlapHeader(w, overlaps)
 	w.WriteHeader(http.StatusCreated)
 }
 
@@ -97,13 +119,15 @@ func (h *SubscriptionHandler) DeleteSubHandler(w http.ResponseWriter, r *http.Re
 
 // UpdateSubHandler godoc
 // @Summary Update a subscription by id
-// @Description Update an existing subscription by its ID
+// @Description Update an existing subscription by its ID. Overlaps with other subscriptions are handled as on creation.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param subscription body entity.Subscription true "Subscription data"
 // @Success 204
+// @Header 204 {string} X-Overlapping-Subscriptions "Ids of the overlapping subscriptions"
 // @Failure 400 {string} string
+// @Failure 409 {string} string
 // @Failure 500 {string} string
 // @Router /subscriptions/{id} [put]
 func (h *SubscriptionHandler) UpdateSubHandler(w http.ResponseWriter, r *http.Request) {
@@ -112,35 +136,397 @@ func (h *SubscriptionHandler) UpdateSubHandler(w http.ResponseWriter, r *http.Re
 	var request entity.Subscription
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
 		slog.Error("Неправильный JSON", "error", err)
 		return
 	}
 	defer r.Body.Close()
 
-	err := h.service.UpdateSubById(ctx, request)
+	overlaps, err := h.service.UpdateSubById(ctx, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные данные подписки", "error", err)
+		return
+	}
+
+	if errors.Is(err, service.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		slog.Info("Подписка пересекается с существующей", "error", err)
+		return
+	}
+
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		slog.Error("Ошибка обновления подписки", "error", err)
 		return
 	}
 
+	setOverlapHeader(w, overlaps)
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// PauseSubHandler godoc
+// @Summary Pause a subscription
+// @Description Stop billing from a month (the current one by default) through another, or until resumed. Pauses must lie within the subscription and must not overlap.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Param pause body entity.PauseRequest false "Paused months"
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Success 200 {object} entity.Subscription "Paused subscription"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) PauseSubHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid subscription id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	var request entity.PauseRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	sub, err := h.service.PauseSubscription(ctx, id, request)
+	h.writePauseResult(w, sub, format, err)
+}
+
+// ResumeSubHandler godoc
+// @Summary Resume a paused subscription
+// @Description End the open pause so that billing restarts in a month, the current one by default. The month should not be in the past, before the pause or after the subscription ends.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Param resume body entity.ResumeRequest false "First billed month"
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Success 200 {object} entity.Subscription "Resumed subscription"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) ResumeSubHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid subscription id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	var request entity.ResumeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	sub, err := h.service.ResumeSubscription(ctx, id, request)
+	h.writePauseResult(w, sub, format, err)
+}
+
+// CancelSubHandler godoc
+// @Summary Cancel a subscription
+// @Description End a subscription with a month, by default the end of the current billing period, and record why. The reason is one of too_expensive, not_using, switched_service, technical_issues, temporary or other; other needs a comment. Cancelling again replaces the earlier cancellation.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Param cancel body entity.CancelRequest true "Last billed month and reason"
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Success 200 {object} entity.Subscription "Cancelled subscription"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) CancelSubHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid subscription id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	var request entity.CancelRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	sub, err := h.service.CancelSubscription(ctx, id, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректная отмена подписки", "error", err)
+		return
+	}
+
+	if errors.Is(err, service.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		slog.Info("Конфликт отмены подписки", "error", err)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка отмены подписки", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(newSubscriptionResponse(*sub, format)); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetChurnReportHandler godoc
+// @Summary Churn report
+// @Description Cancellations ending within a period, by default the last twelve months, counted by service and reason. lost_monthly sums the monthly prices of the cancelled subscriptions.
+// @Tags reports
+// @Produce json
+// @Param user_id query string false "Only cancellations of this user" Format(uuid)
+// @Param from_date query string false "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY"
+// @Param to_date query string false "Last month of the period, same formats (defaults to the current month with basis=charges)"
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {object} entity.ChurnReport
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /reports/churn [get]
+func (h *SubscriptionHandler) GetChurnReportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := subscriptionFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	report, err := h.service.GetChurnReport(ctx, filter)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("некорректный период", "error", err)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка построения отчёта об оттоке", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(newChurnReportResponse(*report, format)); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetSettlementHandler godoc
+// @Summary Settlement of shared subscriptions
+// @Description Who owes whom for the shared subscriptions within a period, by default the current month: every sharing user owes the paying user their share. Debts between two users are netted.
+// @Tags reports
+// @Produce json
+// @Param user_id query string false "Only debts of or to this user" Format(uuid)
+// @Param service_name query string false "Only subscriptions of this service or catalog alias"
+// @Param category query string false "Only subscriptions of this category"
+// @Param tag query string false "Only subscriptions with this tag"
+// @Param from_date query string false "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY (defaults to to_date)"
+// @Param to_date query string false "Last month of the period, same formats (defaults to the current month)"
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {object} entity.Settlement
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /reports/settlement [get]
+func (h *SubscriptionHandler) GetSettlementHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := subscriptionFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	settlement, err := h.service.GetSettlement(ctx, filter)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("некорректный период", "error", err)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка расчёта взаиморасчётов", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(newSettlementResponse(*settlement, format)); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// writePauseResult answers a pause or resume request.
+func (h *SubscriptionHandler) writePauseResult(w http.ResponseWriter, sub *entity.Subscription, format entity.DateFormat, err error) {
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректная пауза подписки", "error", err)
+		return
+	}
+
+	if errors.Is(err, service.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		slog.Info("Конфликт паузы подписки", "error", err)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка паузы подписки", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(newSubscriptionResponse(*sub, format)); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
 // GetAllSubsHandler godoc
 // @Summary Get all subscriptions
-// @Description Show all existing subscriptions
+// @Description Show existing subscriptions with optional filters, in id order
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Param user_id query string false "Filter by user ID" Format(uuid)
+// @Param service_name query string false "Filter by service name or catalog alias"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param from_date query string false "Only subscriptions active in or after this month"
+// @Param to_date query string false "Only subscriptions active in or before this month"
+// @Param filter query string false "Filter expression comparing the fields id, price, service_name, category, billing_period, user_id, start_date and end_date with literals (strings and months double-quoted, * and ? wildcards with ~ and !~) and calling active_in, has_tag and shared, combined with and, or, not and parentheses"
+// @Param limit query int false "Page size, 1 to 100 (default: all subscriptions)"
+// @Param offset query int false "Number of subscriptions to skip"
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
 // @Success 200 {array} entity.Subscription "List of all subscriptions"
+// @Failure 400 {string} string
 // @Failure 500 {string} string
 // @Router /subscriptions [get]
 func (h *SubscriptionHandler) GetAllSubsHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	subs, err := h.service.GetAllSubscriptions(ctx)
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter, err := subscriptionFilterFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+
+	if err := filterExprFromQuery(query, &filter); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("ошибка разбора выражения фильтра", "error", err)
+		return
+	}
+
+	page, err := pageFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга пагинации", "error", err)
+		return
+	}
+
+	subs, err := h.service.ListSubscriptions(ctx, filter, page)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		slog.Error("Ошибка чтения подписок", "error", err)
@@ -150,7 +536,199 @@ func (h *SubscriptionHandler) GetAllSubsHandler(w http.ResponseWriter, r *http.R
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	if err := json.NewEncoder(w).Encode(subs); err != nil {
+	if err := json.NewEncoder(w).Encode(newSubscriptionResponses(subs, format)); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// SearchSubsHandler godoc
+// @Summary Search subscriptions by service name
+// @Description Find the subscriptions whose service name contains the text or resembles it, tolerating typos such as netflx for Netflix, most similar first. Takes the filters and pagination of the listing.
+// @Tags subscriptions
+// @Produce json
+// @Param q query string true "Text to search for"
+// @Param limit query int false "Page size, 1 to 100 (default 20)"
+// @Param offset query int false "Number of subscriptions to skip"
+// @Param user_id query string false "Filter by user ID" Format(uuid)
+// @Param service_name query string false "Filter by service name or catalog alias"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param from_date query string false "Only subscriptions active in or after this month"
+// @Param to_date query string false "Only subscriptions active in or before this month"
+// @Param filter query string false "Filter expression comparing the fields id, price, service_name, category, billing_period, user_id, start_date and end_date with literals (strings and months double-quoted, * and ? wildcards with ~ and !~) and calling active_in, has_tag and shared, combined with and, or, not and parentheses"
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {array} entity.Subscription "Matching subscriptions"
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /subscriptions/search [get]
+func (h *SubscriptionHandler) SearchSubsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	filter, err := subscriptionFilterFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+
+	if err := filterExprFromQuery(query, &filter); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("ошибка разбора выражения фильтра", "error", err)
+		return
+	}
+
+	page, err := pageFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга пагинации", "error", err)
+		return
+	}
+
+	subs, err := h.service.SearchSubscriptions(ctx, filter, query.Get("q"), page)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка поиска подписок", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(newSubscriptionResponses(subs, format)); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// AutocompleteServiceNamesHandler godoc
+// @Summary Autocomplete service names
+// @Description Distinct service names of the subscriptions starting with the text, then those containing or resembling it; names differing only in case are suggested once. Takes the filters and pagination of the listing.
+// @Tags subscriptions
+// @Produce json
+// @Param q query string true "Text typed so far"
+// @Param limit query int false "Number of names, 1 to 100 (default 10)"
+// @Param offset query int false "Number of names to skip"
+// @Param user_id query string false "Only names of this user's subscriptions" Format(uuid)
+// @Param service_name query string false "Filter by service name or catalog alias"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param from_date query string false "Only subscriptions active in or after this month"
+// @Param to_date query string false "Only subscriptions active in or before this month"
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {array} string "Service names"
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /subscriptions/autocomplete [get]
+func (h *SubscriptionHandler) AutocompleteServiceNamesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	filter, err := subscriptionFilterFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+
+	page, err := pageFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга пагинации", "error", err)
+		return
+	}
+
+	names, err := h.service.SuggestServiceNames(ctx, filter, query.Get("q"), page)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка подбора названий сервисов", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(names); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetTrialConversionsHandler godoc
+// @Summary Get trials converting to paid soon
+// @Description Subscriptions whose first paid month after a free trial starts within the given number of days
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param days query int false "How many days ahead to look (default 7, at most 366)"
+// @Param user_id query string false "Filter by user ID" Format(uuid)
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {array} entity.TrialConversion "Trial conversions, soonest first"
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /subscriptions/trials [get]
+func (h *SubscriptionHandler) GetTrialConversionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	filter, err := subscriptionFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+
+	days := 7
+	if v := r.URL.Query().Get("days"); v != "" {
+		days, err = strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid days", http.StatusBadRequest)
+			slog.Error("ошибка парсинга days", "error", err)
+			return
+		}
+	}
+
+	conversions, err := h.service.GetTrialConversions(ctx, filter.UserId, days)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения пробных периодов", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(newTrialConversionResponses(conversions, format)); err != nil {
 		slog.Error("Ошибка сериализации", "error", err)
 	}
 }
@@ -162,6 +740,7 @@ func (h *SubscriptionHandler) GetAllSubsHandler(w http.ResponseWriter, r *http.R
 // @Accept json
 // @Produce json
 // @Param id path int true "Subscription ID"
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
 // @Success 200 {object} entity.Subscription "Subscription found"
 // @Failure 400 {string} string
 // @Failure 500 {string} string
@@ -177,6 +756,13 @@ func (h *SubscriptionHandler) GetSubHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
 	sub, err := h.service.GetSubscriptionById(ctx, id)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -187,22 +773,29 @@ func (h *SubscriptionHandler) GetSubHandler(w http.ResponseWriter, r *http.Reque
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	if err := json.NewEncoder(w).Encode(sub); err != nil {
+	if err := json.NewEncoder(w).Encode(newSubscriptionResponse(*sub, format)); err != nil {
 		slog.Error("Ошибка сериализации", "error", err)
 	}
 }
 
 // GetTotalCostHandler godoc
 // @Summary Get total cost of subscriptions
-// @Description Calculate total cost of subscriptions with optional filters
+// @Description Total the subscriptions with optional filters. With basis=price, the default, the price of each subscription starting in or after from_date and ending by to_date is added once. With basis=charges, every month a subscription is billed in within the period adds the amount charged that month; the period then ends with to_date, or the current month without it. The response states the basis and period used. With user_id, shared subscriptions count for the user's share only; grouping by user splits shared subscriptions between their users.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param user_id query string false "Filter by user ID" Format(uuid)
-// @Param service_name query string false "Filter by service name"
-// @Param from_date query string false "Filter by start date" Format(date)
-// @Param to_date query string false "Filter by end date" Format(date)
-// @Success 200 {object} map[string]int "Total cost"
+// @Param service_name query string false "Filter by service name or catalog alias"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param from_date query string false "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY"
+// @Param to_date query string false "Last month of the period, same formats (defaults to the current month)"
+// @Param filter query string false "Filter expression comparing the fields id, price, service_name, category, billing_period, user_id, start_date and end_date with literals (strings and months double-quoted, * and ? wildcards with ~ and !~) and calling active_in, has_tag and shared, combined with and, or, not and parentheses"
+// @Param group_by query string false "Break the total down by category, tag, canonical service or user" Enums(category, tag, service, user)
+// @Param basis query string false "What is added up: the price of each subscription within the dates once (default) or the monthly charges over the period" Enums(price, charges)
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {object} entity.TotalCost "Total cost"
 // @Failure 400 {string} string
 // @Failure 500 {string} string
 // @Router /subscriptions/total [get]
@@ -211,27 +804,47 @@ func (h *SubscriptionHandler) GetTotalCostHandler(w http.ResponseWriter, r *http
 
 	query := r.URL.Query()
 
-	serviceName := query.Get("service_name")
-	userIDStr := query.Get("user_id")
-	fromDate := query.Get("from_date")
-	toDateStr := query.Get("to_date")
-	var toDate *string = nil
-	if toDateStr != "" {
-		toDate = &toDateStr
+	filter, err := subscriptionFilterFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
 	}
 
-	var userID uuid.UUID
-	if userIDStr != "" {
-		parsed, err := uuid.Parse(userIDStr)
-		if err != nil {
-			http.Error(w, "invalid user_id", http.StatusBadRequest)
-			slog.Error("ошибка парсинга id", "error", err)
-			return
-		}
-		userID = parsed
+	if err := filterExprFromQuery(query, &filter); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("ошибка разбора выражения фильтра", "error", err)
+		return
+	}
+
+	groupBy, err := entity.ParseGroupBy(query.Get("group_by"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга группировки", "error", err)
+		return
+	}
+
+	basis, err := entity.ParseCostBasis(query.Get("basis"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("ошибка парсинга основы подсчёта", "error", err)
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	total, err := h.service.GetTotalCostGrouped(ctx, filter, basis, groupBy)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("некорректный период", "error", err)
+		return
 	}
 
-	total, err := h.service.GetTotalCost(ctx, userID, serviceName, fromDate, toDate)
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		slog.Error("ошибка получения фильтрации", "error", err)
@@ -241,9 +854,311 @@ func (h *SubscriptionHandler) GetTotalCostHandler(w http.ResponseWriter, r *http
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	if err := json.NewEncoder(w).Encode(map[string]int{
-		"total": total,
-	}); err != nil {
+	if err := json.NewEncoder(w).Encode(newTotalCostResponse(total, format)); err != nil {
 		slog.Error("ошибка сериализации", "error", err)
 	}
 }
+
+// GetForecastHandler godoc
+// @Summary Forecast spend
+// @Description Project what subscriptions will charge month by month, starting with the next month, from their end dates, trials, promotions, pauses, billing periods and scheduled price changes as known today. With user_id, shared subscriptions count for the user's share only.
+// @Tags subscriptions
+// @Produce json
+// @Param months query int false "Number of months to forecast, 1 to 60 (default 3)"
+// @Param user_id query string false "Filter by user ID" Format(uuid)
+// @Param service_name query string false "Filter by service name or catalog alias"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param group_by query string false "Break every month down by category, tag, canonical service or user" Enums(category, tag, service, user)
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {object} entity.Forecast
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /subscriptions/forecast [get]
+func (h *SubscriptionHandler) GetForecastHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	filter, err := subscriptionFilterFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+
+	groupBy, err := entity.ParseGroupBy(query.Get("group_by"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга группировки", "error", err)
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	months := 3
+	if v := query.Get("months"); v != "" {
+		months, err = strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid months", http.StatusBadRequest)
+			slog.Error("ошибка парсинга months", "error", err)
+			return
+		}
+	}
+
+	forecast, err := h.service.GetForecast(ctx, filter, months, groupBy)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные параметры прогноза", "error", err)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка построения прогноза", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(newForecastResponse(*forecast, format)); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetOverlapsHandler godoc
+// @Summary Overlapping subscriptions
+// @Description Pairs of subscriptions of the same user to the same canonical service that share at least one month, with the months they share; one of each pair is likely a duplicate.
+// @Tags reports
+// @Produce json
+// @Param user_id query string false "Only overlaps of this user" Format(uuid)
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {array} entity.Overlap
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /reports/overlaps [get]
+func (h *SubscriptionHandler) GetOverlapsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := subscriptionFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	overlaps, err := h.service.GetOverlaps(ctx, filter)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка поиска пересечений подписок", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(newOverlapResponses(overlaps, format)); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetAnomaliesHandler godoc
+// @Summary Spending anomalies
+// @Description Unusual findings in a month, most severe first: monthly spend of a user or on a service, counting the monthly equivalents of quarterly and yearly subscriptions, rising from the month before by at least jump_percent, subscriptions priced at least price_factor times the median of the same service, as monthly equivalents of their billing periods (with at least 3 subscriptions to compare), and subscriptions still active forgotten_months after most subscriptions to the same service typically ended.
+// @Tags reports
+// @Produce json
+// @Param user_id query string false "Only findings of this user, whose spend counts their share of shared subscriptions" Format(uuid)
+// @Param service_name query string false "Only findings on subscriptions to this service or catalog alias"
+// @Param category query string false "Only findings on subscriptions of this category"
+// @Param tag query string false "Only findings on subscriptions with this tag"
+// @Param month query string false "Month to check: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY (defaults to the current month); from_date and to_date are rejected"
+// @Param jump_percent query int false "Month-over-month increase flagged, in percent (default 50)"
+// @Param price_factor query number false "Multiple of the service median flagged (default 2)"
+// @Param forgotten_months query int false "Months after the typical cancellation flagged (default 6)"
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {object} entity.AnomalyReport
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /reports/anomalies [get]
+func (h *SubscriptionHandler) GetAnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	filter, err := subscriptionFilterFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	month := entity.CurrentYearMonth()
+	if v := query.Get("month"); v != "" {
+		month, err = entity.ParseYearMonth(v)
+		if err != nil {
+			http.Error(w, "invalid month: "+err.Error(), http.StatusBadRequest)
+			slog.Error("ошибка парсинга month", "error", err)
+			return
+		}
+	}
+
+	opts := entity.DefaultAnomalyOptions()
+	if v := query.Get("jump_percent"); v != "" {
+		opts.JumpPercent, err = strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid jump_percent", http.StatusBadRequest)
+			slog.Error("ошибка парсинга jump_percent", "error", err)
+			return
+		}
+	}
+	if v := query.Get("price_factor"); v != "" {
+		opts.PriceFactor, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			http.Error(w, "invalid price_factor", http.StatusBadRequest)
+			slog.Error("ошибка парсинга price_factor", "error", err)
+			return
+		}
+	}
+	if v := query.Get("forgotten_months"); v != "" {
+		opts.ForgottenMonths, err = strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid forgotten_months", http.StatusBadRequest)
+			slog.Error("ошибка парсинга forgotten_months", "error", err)
+			return
+		}
+	}
+
+	report, err := h.service.GetAnomalies(ctx, filter, month, opts)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные параметры поиска аномалий", "error", err)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка поиска аномалий", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := anomalyReportResponse{AnomalyReport: *report, Month: report.Month.Format(format)}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// setOverlapHeader reports the subscriptions a written subscription
+// overlaps.
+func setOverlapHeader(w http.ResponseWriter, overlaps []int) {
+	if len(overlaps) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(overlaps))
+	for _, id := range overlaps {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	w.Header().Set("X-Overlapping-Subscriptions", strings.Join(ids, ","))
+}
+
+// subscriptionFilterFromQuery reads the user_id, service_name, category, tag,
+// from_date and to_date filters shared by the listing and totals endpoints.
+func subscriptionFilterFromQuery(query url.Values) (entity.SubscriptionFilter, error) {
+	filter := entity.SubscriptionFilter{
+		ServiceName: query.Get("service_name"),
+		Category:    query.Get("category"),
+		Tag:         query.Get("tag"),
+	}
+
+	if userIDStr := query.Get("user_id"); userIDStr != "" {
+		parsed, err := uuid.Parse(userIDStr)
+		if err != nil {
+			return filter, errors.New("invalid user_id")
+		}
+		filter.UserId = parsed
+	}
+
+	if fromDate := query.Get("from_date"); fromDate != "" {
+		parsed, err := entity.ParseYearMonth(fromDate)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from_date: %w", err)
+		}
+		filter.From = &parsed
+	}
+
+	if toDate := query.Get("to_date"); toDate != "" {
+		parsed, err := entity.ParseYearMonth(toDate)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to_date: %w", err)
+		}
+		filter.To = &parsed
+	}
+
+	return filter, nil
+}
+
+// pageFromQuery reads the limit and offset query parameters.
+func pageFromQuery(query url.Values) (entity.Page, error) {
+	var page entity.Page
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return page, errors.New("invalid limit")
+		}
+		page.Limit = limit
+	}
+
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil {
+			return page, errors.New("invalid offset")
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// filterExprFromQuery parses the filter expression of the filter query
+// parameter, if any, into the filter.
+func filterExprFromQuery(query url.Values, filter *entity.SubscriptionFilter) error {
+	raw := query.Get("filter")
+	if raw == "" {
+		return nil
+	}
+
+	node, err := expr.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	filter.Expr = node
+
+	return nil
+}
@@ -0,0 +1,534 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/entity"
+	"test_task/internal/ical"
+	"test_task/internal/service"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type UserHandler struct {
+	service    *service.UserService
+	subService *service.SubscriptionService
+}
+
+func NewUserHandler(service *service.UserService, subService *service.SubscriptionService) *UserHandler {
+	return &UserHandler{
+		service:    service,
+		subService: subService,
+	}
+}
+
+// CreateUserHandler godoc
+//
+// @Summary Create a new user
+// @Description Create a user; the id is generated unless given
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body entity.User true "User data"
+// @Success 201 {object} entity.User "Created user"
+// @Failure 400 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /users [post]
+func (h *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request entity.User
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	user, err := h.service.CreateUser(ctx, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные данные пользователя", "error", err)
+		return
+	}
+
+	if errors.Is(err, service.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		slog.Info("Конфликт при создании пользователя", "error", err)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка создания пользователя", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetAllUsersHandler godoc
+// @Summary Get all users
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {array} entity.User "List of all users"
+// @Failure 500 {string} string
+// @Router /users [get]
+func (h *UserHandler) GetAllUsersHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	users, err := h.service.GetAllUsers(ctx)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения пользователей", "error", err)
+		return
+	}
+
+	if users == nil {
+		users = []entity.User{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(users); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetUserHandler godoc
+// @Summary Get a user by id
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" Format(uuid)
+// @Success 200 {object} entity.User "User found"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /users/{id} [get]
+func (h *UserHandler) GetUserHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.GetUserById(ctx, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения пользователя", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// UpdateUserHandler godoc
+// @Summary Update a user by id
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" Format(uuid)
+// @Param user body entity.User true "User data"
+// @Success 204
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /users/{id} [put]
+func (h *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var request entity.User
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	request.Id = id
+
+	err := h.service.UpdateUser(ctx, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные данные пользователя", "error", err)
+		return
+	}
+
+	if errors.Is(err, service.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		slog.Info("Конфликт при обновлении пользователя", "error", err)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка обновления пользователя", "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteUserHandler godoc
+// @Summary Delete a user by id
+// @Description Delete a user that has no subscriptions, shares no subscriptions and has no statements, with their budgets and notification preferences. Other users can be made inactive instead.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" Format(uuid)
+// @Success 204
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /users/{id} [delete]
+func (h *UserHandler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.service.DeleteUser(ctx, id)
+	if errors.Is(err, service.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		slog.Info("Конфликт при удалении пользователя", "error", err)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка удаления пользователя", "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUserSubsHandler godoc
+// @Summary Get subscriptions of a user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" Format(uuid)
+// @Param date_format query string false "Date format of the response: mm-yyyy (default) or iso" Enums(mm-yyyy, iso)
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {array} entity.Subscription "Subscriptions of the user"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /users/{id}/subscriptions [get]
+func (h *UserHandler) GetUserSubsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	format, err := dateFormatFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Неизвестный формат даты", "error", err)
+		return
+	}
+
+	if !h.userExists(w, r, id) {
+		return
+	}
+
+	subs, err := h.subService.GetSubscriptions(ctx, entity.SubscriptionFilter{UserId: id})
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения подписок", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(newSubscriptionResponses(subs, format)); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetUserTotalHandler godoc
+// @Summary Get total cost of a user's subscriptions
+// @Description Same as /subscriptions/total restricted to one user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" Format(uuid)
+// @Param service_name query string false "Filter by service name or catalog alias"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param from_date query string false "First month of the period: MM-YYYY, YYYY-MM, YYYY-MM-DD or MM/YYYY"
+// @Param to_date query string false "Last month of the period, same formats (defaults to the current month)"
+// @Param X-Read-Your-Writes header bool false "Read from the primary database instead of a replica"
+// @Success 200 {object} map[string]int "Total cost"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /users/{id}/total [get]
+func (h *UserHandler) GetUserTotalHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	filter, err := subscriptionFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("ошибка парсинга фильтров", "error", err)
+		return
+	}
+	filter.UserId = id
+
+	if !h.userExists(w, r, id) {
+		return
+	}
+
+	total, err := h.subService.GetTotalCost(ctx, filter)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("некорректный период", "error", err)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		slog.Error("ошибка получения фильтрации", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(map[string]int{
+		"total": total,
+	}); err != nil {
+		slog.Error("ошибка сериализации", "error", err)
+	}
+}
+
+// IssueCalendarTokenHandler godoc
+// @Summary Issue a calendar token
+// @Description Generate the secret token authenticating the calendar feed of a user, revoking the previous one, which has to be given to replace it. The token is only returned here.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID" Format(uuid)
+// @Param X-Calendar-Token header string false "Current calendar token, required once one was issued"
+// @Success 201 {object} entity.CalendarToken
+// @Failure 400 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /users/{id}/calendar-token [post]
+func (h *UserHandler) IssueCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := h.service.IssueCalendarToken(ctx, id, r.Header.Get("X-Calendar-Token"))
+	if err == sql.ErrNoRows {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, service.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка выпуска токена календаря", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(token); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetCalendarHandler godoc
+// @Summary Calendar feed of a user
+// @Description iCalendar (RFC 5545) feed with an all-day event on each upcoming billing date of the subscriptions the user pays or shares, and on the last day of each of their subscriptions that ends, prices in the descriptions. Authenticated by the token from /users/{id}/calendar-token; a wrong token answers 404.
+// @Tags users
+// @Produce text/calendar
+// @Param id path string true "User ID" Format(uuid)
+// @Param token query string true "Calendar token"
+// @Param months query int false "Number of months ahead, the current one included, 1 to 36 (default 12)"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /users/{id}/calendar.ics [get]
+func (h *UserHandler) GetCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.service.CheckCalendarToken(ctx, id, r.URL.Query().Get("token"))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Calendar not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка проверки токена календаря", "error", err)
+		return
+	}
+
+	months := 12
+	if v := r.URL.Query().Get("months"); v != "" {
+		months, err = strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid months", http.StatusBadRequest)
+			slog.Error("ошибка парсинга months", "error", err)
+			return
+		}
+	}
+
+	events, err := h.subService.GetCalendarEvents(ctx, id, months)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка построения календаря", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="calendar.ics"`)
+	w.WriteHeader(http.StatusOK)
+
+	if err := ical.Write(w, "Subscriptions", newCalendarEvents(events), time.Now()); err != nil {
+		slog.Error("Ошибка записи календаря", "error", err)
+	}
+}
+
+// newCalendarEvents renders renewals on the first day of the billed month
+// and ends on the last day of the last month.
+func newCalendarEvents(events []entity.CalendarEvent) []ical.Event {
+	out := make([]ical.Event, 0, len(events))
+	for _, e := range events {
+		sub := e.Subscription
+		first := e.Month.Time()
+
+		switch e.Kind {
+		case entity.CalendarRenewal:
+			description := fmt.Sprintf("%s (subscription %d) renews and charges %d.", sub.ServiceName, sub.Id, e.Amount)
+			if e.Share != e.Amount {
+				description += fmt.Sprintf(" Your share is %d.", e.Share)
+			}
+
+			out = append(out, ical.Event{
+				UID:         fmt.Sprintf("renewal-%d-%s@subscriptions", sub.Id, first.Format("200601")),
+				Date:        first,
+				Summary:     fmt.Sprintf("%s renews: %d", sub.ServiceName, e.Amount),
+				Description: description,
+			})
+		case entity.CalendarEnd:
+			out = append(out, ical.Event{
+				UID:         fmt.Sprintf("end-%d@subscriptions", sub.Id),
+				Date:        first.AddDate(0, 1, -1),
+				Summary:     fmt.Sprintf("%s ends", sub.ServiceName),
+				Description: fmt.Sprintf("%s (subscription %d) ends after %s and will not renew.", sub.ServiceName, sub.Id, e.Month),
+			})
+		}
+	}
+
+	return out
+}
+
+// userExists answers 404 and returns false when there is no such user.
+func (h *UserHandler) userExists(w http.ResponseWriter, r *http.Request, id uuid.UUID) bool {
+	_, err := h.service.GetUserById(r.Context(), id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return false
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения пользователя", "error", err)
+		return false
+	}
+
+	return true
+}
+
+// userIDFromPath parses the {id} path variable, answering 400 when it is not
+// a UUID.
+func userIDFromPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/entity"
+	"test_task/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+	}
+}
+
+// CreateWebhookHandler godoc
+//
+// @Summary Register a webhook
+// @Description The webhook receives the chosen event types, all of them when none are given. Its URL has to resolve to public addresses only. Deliveries are signed: X-Webhook-Signature is "sha256=" and the hex HMAC-SHA256 of X-Webhook-Timestamp, "." and the body, keyed with the secret. Without a secret one is generated; it is only returned here.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body entity.Webhook true "Webhook"
+// @Success 201 {object} entity.Webhook "Created webhook with its secret"
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request entity.Webhook
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	webhook, err := h.service.CreateWebhook(ctx, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные данные вебхука", "error", err)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка создания вебхука", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetAllWebhooksHandler godoc
+// @Summary Get all webhooks
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Success 200 {array} entity.Webhook "Webhooks"
+// @Failure 500 {string} string
+// @Router /webhooks [get]
+func (h *WebhookHandler) GetAllWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhooks, err := h.service.GetAllWebhooks(ctx)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения вебхуков", "error", err)
+		return
+	}
+
+	if webhooks == nil {
+		webhooks = []entity.Webhook{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(webhooks); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// GetWebhookHandler godoc
+// @Summary Get a webhook by id
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} entity.Webhook "Webhook"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid webhook id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	webhook, err := h.service.GetWebhookById(ctx, id)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения вебхука", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
+
+// UpdateWebhookHandler godoc
+// @Summary Update a webhook by id
+// @Description The secret is kept unless a new one is given. The URL has to resolve to public addresses only.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param webhook body entity.Webhook true "Webhook"
+// @Success 204
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid webhook id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	var request entity.Webhook
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		slog.Error("Неправильный JSON", "error", err)
+		return
+	}
+	defer r.Body.Close()
+
+	request.Id = id
+
+	err = h.service.UpdateWebhook(ctx, request)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Info("Некорректные данные вебхука", "error", err)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка обновления вебхука", "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteWebhookHandler godoc
+// @Summary Delete a webhook by id
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 204
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid webhook id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	err = h.service.DeleteWebhook(ctx, id)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка удаления вебхука", "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeliveriesHandler godoc
+// @Summary Get the delivery log of a webhook
+// @Description Latest deliveries first. Failed attempts are retried with exponential backoff until the delivery succeeds or fails for good.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param status query string false "Only deliveries with this status" Enums(pending, succeeded, failed)
+// @Param limit query int false "Maximum number of deliveries (default 50, at most 500)"
+// @Success 200 {array} entity.WebhookDelivery "Deliveries"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid webhook id", http.StatusBadRequest)
+		slog.Error("ошибка парсинга id", "error", err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			slog.Error("ошибка парсинга limit", "error", err)
+			return
+		}
+	}
+
+	deliveries, err := h.service.GetDeliveries(ctx, id, query.Get("status"), limit)
+	if errors.Is(err, service.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Ошибка чтения доставок вебхука", "error", err)
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []entity.WebhookDelivery{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(deliveries); err != nil {
+		slog.Error("Ошибка сериализации", "error", err)
+	}
+}
@@ -0,0 +1,94 @@
+// Package ical writes iCalendar (RFC 5545) feeds of all-day events.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest content line allowed before folding.
+const maxLineOctets = 75
+
+// Event is an all-day event.
+type Event struct {
+	// UID identifies the event across updates of the feed.
+	UID         string
+	Date        time.Time
+	Summary     string
+	Description string
+}
+
+// Write writes a calendar named name with the events; stamp is the time the
+// feed is generated.
+func Write(w io.Writer, name string, events []Event, stamp time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		bw.WriteString(fold(s))
+		bw.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//test_task//Subscriptions//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escape(name))
+
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + escape(e.UID))
+		line("DTSTAMP:" + stamp.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE:" + e.Date.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + e.Date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:" + escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + escape(e.Description))
+		}
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// escape escapes a TEXT value.
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// fold splits a content line longer than 75 octets into lines continued
+// with a leading space, without splitting UTF-8 sequences.
+func fold(s string) string {
+	if len(s) <= maxLineOctets {
+		return s
+	}
+
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space that counts towards the
+		// limit.
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(s)
+
+	return b.String()
+}
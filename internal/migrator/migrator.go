@@ -0,0 +1,315 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// lockKey identifies the advisory lock held while migrations run, so that
+// several replicas starting at once apply them one at a time.
+const lockKey = 7318245901
+
+// The version table has the same layout as the one used by migrate/migrate,
+// so databases migrated by the old container keep their version.
+const createVersionTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		dirty BOOLEAN NOT NULL
+	)
+`
+
+var fileNameRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+var ErrDirty = errors.New("database is in a dirty migration state, fix it manually")
+
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version    uint
+	Dirty      bool
+	Migrations []MigrationStatus
+}
+
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+
+	return m.Goto(ctx, m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down rolls back the given number of applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps should be positive")
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		idx := m.index(current)
+		if current != 0 && idx < 0 {
+			return fmt.Errorf("unknown current version %d", current)
+		}
+
+		target := uint(0)
+		if idx-steps >= 0 {
+			target = m.migrations[idx-steps].Version
+		}
+
+		return m.migrate(ctx, conn, current, target)
+	})
+}
+
+// Goto migrates up or down to the given version. Version 0 means an empty
+// schema.
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("unknown version %d", version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		return m.migrate(ctx, conn, current, version)
+	})
+}
+
+// Status reports the current version and which migrations are applied. It
+// only reads the version table, without waiting for a migration in progress,
+// and reports version 0 for a database never migrated.
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	var status Status
+
+	var exists bool
+	if err := m.db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return Status{}, err
+	}
+
+	if exists {
+		err := m.db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).
+			Scan(&status.Version, &status.Dirty)
+		if err != nil && err != sql.ErrNoRows {
+			return Status{}, err
+		}
+	}
+
+	for _, mig := range m.migrations {
+		status.Migrations = append(status.Migrations, MigrationStatus{
+			Version: mig.Version,
+			Name:    mig.Name,
+			Applied: mig.Version <= status.Version,
+		})
+	}
+
+	return status, nil
+}
+
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn, current, target uint) error {
+	if current < target {
+		for _, mig := range m.migrations {
+			if mig.Version <= current || mig.Version > target {
+				continue
+			}
+
+			if err := apply(ctx, conn, mig.Up, mig.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+			}
+			slog.Info("Миграция применена", "version", mig.Version, "name", mig.Name)
+		}
+
+		return nil
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if mig.Version > current || mig.Version <= target {
+			continue
+		}
+
+		prev := uint(0)
+		if i > 0 {
+			prev = m.migrations[i-1].Version
+		}
+
+		if err := apply(ctx, conn, mig.Down, prev); err != nil {
+			return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+		}
+		slog.Info("Миграция откачена", "version", mig.Version, "name", mig.Name)
+	}
+
+	return nil
+}
+
+// apply runs a migration script and records the resulting version in a single
+// transaction, so a failed script leaves neither schema changes nor a dirty
+// version behind.
+func apply(ctx context.Context, conn *sql.Conn, script string, version uint) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if script != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+
+	if version != 0 {
+		_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations(version, dirty) VALUES($1, false)`, version)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey)
+
+	if _, err := conn.ExecContext(ctx, createVersionTable); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) index(version uint) int {
+	for i, mig := range m.migrations {
+		if mig.Version == version {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func currentVersion(ctx context.Context, conn *sql.Conn) (uint, error) {
+	var version uint
+	var dirty bool
+
+	err := conn.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, ErrDirty
+	}
+
+	return version, nil
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		match := fileNameRe.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[uint(version)]
+		if !ok {
+			mig = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = mig
+		}
+		if mig.Name != match[2] {
+			return nil, fmt.Errorf("conflicting names for migration %d", version)
+		}
+
+		if match[3] == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
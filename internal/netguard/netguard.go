@@ -0,0 +1,92 @@
+// Package netguard keeps the requests made to user-supplied URLs from
+// reaching the network of the server itself: loopback, private, link-local
+// and other non-public addresses are refused.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrNonPublicAddress is returned when connecting to a non-public address.
+var ErrNonPublicAddress = errors.New("non-public address")
+
+// reserved are the non-public ranges netip does not classify.
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// IsPublic reports whether addr is a global unicast address outside the
+// loopback, private, link-local and reserved ranges.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, p := range reserved {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckHost resolves host and returns an error unless all its addresses are
+// public.
+func CheckHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("host %s cannot be resolved", host)
+	}
+
+	for _, addr := range addrs {
+		if !IsPublic(addr) {
+			return fmt.Errorf("host %s resolves to %w %s", host, ErrNonPublicAddress, addr.Unmap())
+		}
+	}
+
+	return nil
+}
+
+// NewClient returns an HTTP client that only connects to public addresses.
+// The address is checked when connecting, so that neither a redirect nor a
+// host resolving differently than when it was checked gets through. No proxy
+// is used, since the address checked would be that of the proxy.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublic(addr.Addr()) {
+				return fmt.Errorf("connect to %s: %w", address, ErrNonPublicAddress)
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
@@ -0,0 +1,36 @@
+package netguard
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("IsPublic(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileNotifier appends notifications to a file as JSON lines, which is handy
+// for local testing.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{
+		path: path,
+	}
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, notification Notification) error {
+	line, err := json.Marshal(struct {
+		Time time.Time `json:"time"`
+		To   string    `json:"to,omitempty"`
+		Notification
+	}{time.Now(), notification.To, notification})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
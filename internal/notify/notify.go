@@ -0,0 +1,52 @@
+// Package notify delivers notifications to users and administrators through
+// pluggable backends.
+package notify
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KindBudgetAlert     = "budget_alert"
+	KindRenewalReminder = "renewal_reminder"
+	KindEndingReminder  = "ending_reminder"
+)
+
+type Notification struct {
+	// Kind identifies the event, e.g. KindBudgetAlert.
+	Kind string `json:"kind"`
+	// UserId is the recipient, or nil for notifications meant for
+	// administrators.
+	UserId *uuid.UUID `json:"user_id"`
+	// To is the address of the recipient on the backend, e.g. an email
+	// address or a webhook URL. Backends with a fixed destination ignore it.
+	To      string `json:"-"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier writes notifications to the application log.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	slog.InfoContext(ctx, "Уведомление",
+		"kind", notification.Kind,
+		"user_id", notification.UserId,
+		"to", notification.To,
+		"subject", notification.Subject,
+		"body", notification.Body,
+	)
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// smtpTimeout bounds a send, dial included, unless ctx ends sooner.
+const smtpTimeout = 30 * time.Second
+
+// SMTPNotifier emails notifications to Notification.To.
+type SMTPNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPNotifier creates a notifier sending through the server at addr
+// (host:port). Without a username mail is sent unauthenticated.
+func NewSMTPNotifier(addr, username, password, from string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPNotifier{
+		addr: addr,
+		auth: auth,
+		from: from,
+	}
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, notification Notification) error {
+	if notification.To == "" {
+		return errors.New("no email address to notify")
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", notification.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", headerValue(notification.Subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(notification.Body)
+	msg.WriteString("\r\n")
+
+	return n.send(ctx, notification.To, []byte(msg.String()))
+}
+
+// send does what smtp.SendMail does, STARTTLS when offered, within
+// smtpTimeout and until ctx is done, so that a stalled server cannot hold up
+// the caller.
+func (n *SMTPNotifier) send(ctx context.Context, to string, msg []byte) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return errors.New("smtp: address contains a line break")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(n.addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+
+	if n.auth != nil {
+		if err := c.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(n.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// headerValue keeps a header value on one line.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
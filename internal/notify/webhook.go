@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"test_task/internal/netguard"
+	"time"
+)
+
+// WebhookNotifier posts notifications as JSON to Notification.To, or to the
+// default URL when it is empty. Notification.To comes from users, so only
+// public addresses are reached through it; the default URL is trusted.
+type WebhookNotifier struct {
+	client     *http.Client
+	userClient *http.Client
+	defaultURL string
+}
+
+func NewWebhookNotifier(defaultURL string) *WebhookNotifier {
+	return &WebhookNotifier{
+		client:     &http.Client{Timeout: 10 * time.Second},
+		userClient: netguard.NewClient(10 * time.Second),
+		defaultURL: defaultURL,
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	url, client := notification.To, n.userClient
+	if url == "" {
+		url, client = n.defaultURL, n.client
+	}
+	if url == "" {
+		return errors.New("no webhook URL to notify")
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+// Package pdf writes simple text documents as PDF 1.4 using the standard
+// Helvetica fonts, so that no font has to be embedded.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 page size and margins, in points.
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+	margin     = 50.0
+)
+
+// Cell is text starting X points from the left margin.
+type Cell struct {
+	X    float64
+	Text string
+}
+
+// Line is a line of text cells. Size is the font size in points; the line
+// takes 1.4 times as much vertical space.
+type Line struct {
+	Size  float64
+	Bold  bool
+	Cells []Cell
+}
+
+// Text returns a line with a single cell.
+func Text(size float64, bold bool, text string) Line {
+	return Line{Size: size, Bold: bold, Cells: []Cell{{Text: text}}}
+}
+
+// Write lays the lines out top to bottom on as many A4 pages as needed and
+// writes the document. Text is encoded in WinAnsi: Cyrillic is
+// transliterated and other characters outside Latin-1 are replaced with "?".
+func Write(w io.Writer, title string, lines []Line) error {
+	var pages []string
+	var content strings.Builder
+	y := pageHeight - margin
+	for _, line := range lines {
+		height := line.Size * 1.4
+		if y-height < margin && content.Len() > 0 {
+			pages = append(pages, content.String())
+			content.Reset()
+			y = pageHeight - margin
+		}
+		y -= height
+
+		font := "F1"
+		if line.Bold {
+			font = "F2"
+		}
+		for _, cell := range line.Cells {
+			fmt.Fprintf(&content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+				font, line.Size, margin+cell.X, y, encode(cell.Text))
+		}
+	}
+	pages = append(pages, content.String())
+
+	// Objects 1 to 4 are the catalog, the page tree and the two fonts, 5 the
+	// document information; every page then takes a page and a content
+	// object.
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // page tree, once the page objects are numbered
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title (%s) /Producer (test_task) >>", encode(title)),
+	}
+
+	var kids []string
+	for _, page := range pages {
+		pageObj := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObj))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, pageObj+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(page), page),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// encode converts text to a WinAnsi string literal body.
+func encode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if t, ok := cyrillic[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteByte(' ')
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+
+	return b.String()
+}
+
+var cyrillic = func() map[rune]string {
+	lower := map[rune]string{
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+		'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+		'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+		'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+		'я': "ya",
+	}
+
+	m := map[rune]string{}
+	for r, t := range lower {
+		m[r] = t
+		upper := []rune(strings.ToUpper(string(r)))[0]
+		if t != "" {
+			m[upper] = strings.ToUpper(t[:1]) + t[1:]
+		} else {
+			m[upper] = ""
+		}
+	}
+
+	return m
+}()
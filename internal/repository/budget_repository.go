@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"test_task/internal/database"
+	"test_task/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+const budgetColumns = `id, name, user_id, category, service_name, period, amount`
+
+type BudgetRepository struct {
+	db *sql.DB
+}
+
+func NewBudgetRepository(cluster *database.Cluster) *BudgetRepository {
+	return &BudgetRepository{
+		db: cluster.Primary(),
+	}
+}
+
+func (r *BudgetRepository) CreateBudget(ctx context.Context, b entity.Budget) (int, error) {
+	query := `
+		INSERT INTO budgets(name, user_id, category, service_name, period, amount)
+		VALUES($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	var id int
+
+	err := r.db.QueryRowContext(ctx, query, b.Name, b.UserId, b.Category, b.ServiceName, b.Period, b.Amount).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *BudgetRepository) GetBudgetById(ctx context.Context, id int) (*entity.Budget, error) {
+	query := `
+		SELECT ` + budgetColumns + `
+		FROM budgets
+		WHERE id = $1
+	`
+
+	b, err := scanBudget(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// GetBudgets returns all budgets, or the budgets of one user when userId is
+// set.
+func (r *BudgetRepository) GetBudgets(ctx context.Context, userId uuid.UUID) ([]entity.Budget, error) {
+	query := `
+		SELECT ` + budgetColumns + `
+		FROM budgets
+	`
+	args := []interface{}{}
+
+	if userId != uuid.Nil {
+		query += fmt.Sprintf(" WHERE user_id = $%d", len(args)+1)
+		args = append(args, userId)
+	}
+	query += " ORDER BY id"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []entity.Budget
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		budgets = append(budgets, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return budgets, nil
+}
+
+func (r *BudgetRepository) UpdateBudget(ctx context.Context, b entity.Budget) error {
+	query := `
+		UPDATE budgets
+		SET name = $1, user_id = $2, category = $3, service_name = $4, period = $5, amount = $6
+		WHERE id = $7
+	`
+
+	res, err := r.db.ExecContext(ctx, query, b.Name, b.UserId, b.Category, b.ServiceName, b.Period, b.Amount, b.Id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *BudgetRepository) DeleteBudget(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// RecordAlert stores an alert unless one was already raised for the same
+// budget period and threshold, and reports whether it was stored.
+func (r *BudgetRepository) RecordAlert(ctx context.Context, a entity.BudgetAlert) (bool, error) {
+	query := `
+		INSERT INTO budget_alerts(budget_id, period_start, threshold, spent)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`
+
+	res, err := r.db.ExecContext(ctx, query, a.BudgetId, a.PeriodStart, a.Threshold, a.Spent)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *BudgetRepository) GetAlerts(ctx context.Context, budgetId int, periodStart entity.YearMonth) ([]entity.BudgetAlert, error) {
+	query := `
+		SELECT budget_id, period_start, threshold, spent, created_at
+		FROM budget_alerts
+		WHERE budget_id = $1 AND period_start = $2
+		ORDER BY threshold
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, budgetId, periodStart)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []entity.BudgetAlert{}
+	for rows.Next() {
+		var a entity.BudgetAlert
+		err := rows.Scan(&a.BudgetId, &a.PeriodStart, &a.Threshold, &a.Spent, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		alerts = append(alerts, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+func scanBudget(row rowScanner) (entity.Budget, error) {
+	var b entity.Budget
+
+	err := row.Scan(
+		&b.Id,
+		&b.Name,
+		&b.UserId,
+		&b.Category,
+		&b.ServiceName,
+		&b.Period,
+		&b.Amount,
+	)
+
+	return b, err
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"test_task/internal/database"
+	"test_task/internal/entity"
+)
+
+type CategoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryRepository(cluster *database.Cluster) *CategoryRepository {
+	return &CategoryRepository{
+		db: cluster.Primary(),
+	}
+}
+
+func (r *CategoryRepository) CreateCategory(ctx context.Context, c entity.Category) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO categories(name) VALUES($1)`, c.Name)
+	return err
+}
+
+func (r *CategoryRepository) GetAllCategories(ctx context.Context) ([]entity.Category, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT name FROM categories ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []entity.Category
+	for rows.Next() {
+		var c entity.Category
+		if err := rows.Scan(&c.Name); err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (r *CategoryRepository) DeleteCategory(ctx context.Context, name string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM categories WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	// serializationFailure is reported when a transaction cannot be
+	// serialized with concurrent ones and has to be retried.
+	serializationFailure = "40001"
+)
+
+// IsUniqueViolation reports whether err was caused by a duplicate value in a
+// unique column.
+func IsUniqueViolation(err error) bool {
+	return hasCode(err, uniqueViolation)
+}
+
+// IsForeignKeyViolation reports whether err was caused by a reference to a
+// missing row or by deleting a row that is still referenced.
+func IsForeignKeyViolation(err error) bool {
+	return hasCode(err, foreignKeyViolation)
+}
+
+// ViolatedConstraint returns the name of the constraint err reports as
+// violated, or "" for other errors.
+func ViolatedConstraint(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Constraint
+	}
+
+	return ""
+}
+
+func hasCode(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"test_task/internal/database"
+	"test_task/internal/entity"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const eventColumns = `id, event_type, payload, created_at`
+
+// EventRepository reads the outbox as an event log and listens for new
+// events.
+type EventRepository struct {
+	db      *sql.DB
+	connStr string
+}
+
+// NewEventRepository creates a repository listening for notifications on a
+// dedicated connection to the primary opened with connStr.
+func NewEventRepository(cluster *database.Cluster, connStr string) *EventRepository {
+	return &EventRepository{
+		db:      cluster.Primary(),
+		connStr: connStr,
+	}
+}
+
+func (r *EventRepository) GetEvent(ctx context.Context, id int64) (*entity.Event, error) {
+	query := `
+		SELECT ` + eventColumns + `
+		FROM outbox_events
+		WHERE id = $1
+	`
+
+	e, err := scanEvent(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+// GetEventsAfter returns up to limit events with ids greater than id, in
+// order.
+func (r *EventRepository) GetEventsAfter(ctx context.Context, id int64, limit int) ([]entity.Event, error) {
+	query := `
+		SELECT ` + eventColumns + `
+		FROM outbox_events
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []entity.Event
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// LatestEventId returns the id of the latest event in the log, 0 when the
+// log is empty.
+func (r *EventRepository) LatestEventId(ctx context.Context) (int64, error) {
+	var latest int64
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM outbox_events`).Scan(&latest)
+	return latest, err
+}
+
+// PrunedThrough returns the highest id of the events pruned from the log, 0
+// when none was.
+func (r *EventRepository) PrunedThrough(ctx context.Context) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `SELECT pruned_through FROM outbox_pruned`).Scan(&id)
+	return id, err
+}
+
+// PruneEvents deletes the events created before the given time, except
+// those webhooks have yet to receive, and returns how many it deleted. The
+// highest id deleted is recorded for PrunedThrough.
+func (r *EventRepository) PruneEvents(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		WITH deleted AS (
+			DELETE FROM outbox_events e
+			WHERE e.created_at < $1
+				AND e.dispatched_at IS NOT NULL
+				AND NOT EXISTS (
+					SELECT 1 FROM webhook_deliveries d
+					WHERE d.event_id = e.id AND d.status = 'pending'
+				)
+			RETURNING e.id
+		), watermark AS (
+			UPDATE outbox_pruned
+			SET pruned_through = GREATEST(pruned_through, (SELECT MAX(id) FROM deleted))
+		)
+		SELECT COUNT(*) FROM deleted
+	`
+
+	var n int64
+	err := r.db.QueryRowContext(ctx, query, before).Scan(&n)
+	return n, err
+}
+
+// Listen calls onEvent with the id of every event committed from now on
+// until ctx is done. The connection is re-established when lost; onReconnect
+// is called then, since events may have been missed meanwhile.
+func (r *EventRepository) Listen(ctx context.Context, onEvent func(id int64), onReconnect func()) error {
+	listener := pq.NewListener(r.connStr, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Error("Ошибка соединения для LISTEN", "error", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(EventsChannel); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			if n == nil {
+				onReconnect()
+				continue
+			}
+
+			id, err := strconv.ParseInt(n.Extra, 10, 64)
+			if err != nil {
+				slog.Error("Неверный id события", "payload", n.Extra)
+				continue
+			}
+			onEvent(id)
+		case <-time.After(90 * time.Second):
+			if err := listener.Ping(); err != nil {
+				slog.Error("Ошибка проверки соединения LISTEN", "error", err)
+			}
+		}
+	}
+}
+
+func scanEvent(row rowScanner) (entity.Event, error) {
+	var e entity.Event
+	var payload []byte
+
+	err := row.Scan(
+		&e.Id,
+		&e.Type,
+		&payload,
+		&e.CreatedAt,
+	)
+	e.Data = payload
+
+	return e, err
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"test_task/internal/expr"
+)
+
+// exprColumns maps the fields of filter expressions to SQL over the
+// unaliased subscription table.
+var exprColumns = map[string]string{
+	"id":           "subscription.id",
+	"service_name": "subscription.service_name",
+	"category":     "subscription.category",
+	// The list price in effect this month: the latest price change that took
+	// effect, or the initial price.
+	"price": `COALESCE((
+		SELECT pc.price FROM subscription_price_changes pc
+		WHERE pc.subscription_id = subscription.id AND pc.effective_month <= CURRENT_DATE
+		ORDER BY pc.effective_month DESC LIMIT 1
+	), subscription.price)`,
+	"billing_period": "subscription.billing_period",
+	"user_id":        "subscription.user_id",
+	"start_date":     "subscription.start_date",
+	"end_date":       "subscription.end_date",
+}
+
+// exprCondition translates a parsed filter expression into a condition over
+// the subscription table. Literals are only ever bound as parameters.
+func exprCondition(node expr.Node, args []interface{}) (string, []interface{}) {
+	bind := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var walk func(node expr.Node) string
+	walk = func(node expr.Node) string {
+		switch n := node.(type) {
+		case *expr.And:
+			return "(" + walk(n.Left) + " AND " + walk(n.Right) + ")"
+		case *expr.Or:
+			return "(" + walk(n.Left) + " OR " + walk(n.Right) + ")"
+		case *expr.Not:
+			return "NOT COALESCE(" + walk(n.X) + ", false)"
+		case *expr.Comparison:
+			column := exprColumns[n.Field]
+			value := n.Value
+			// Categories are stored normalized; patterns match case-insensitively
+			// anyway.
+			if category, ok := value.(string); ok && n.Field == "category" && n.Op != "~" && n.Op != "!~" {
+				value = normalizeCategory(category)
+			}
+			switch {
+			case value == nil && n.Op == "=":
+				return column + " IS NULL"
+			case value == nil:
+				return column + " IS NOT NULL"
+			case n.Op == "~":
+				return column + " ILIKE " + bind(likePattern(value.(string)))
+			case n.Op == "!~":
+				return "NOT COALESCE(" + column + " ILIKE " + bind(likePattern(value.(string))) + ", false)"
+			case n.Op == "!=":
+				return column + " IS DISTINCT FROM " + bind(value)
+			default:
+				return column + " " + n.Op + " " + bind(value)
+			}
+		case *expr.Call:
+			switch n.Func {
+			case "active_in":
+				month := bind(n.Args[0])
+				return fmt.Sprintf("(subscription.start_date <= %[1]s AND (subscription.end_date IS NULL OR subscription.end_date >= %[1]s))", month)
+			case "has_tag":
+				return "EXISTS (SELECT 1 FROM subscription_tags t WHERE t.subscription_id = subscription.id AND t.tag = lower(trim(" + bind(n.Args[0]) + ")))"
+			case "shared":
+				return "EXISTS (SELECT 1 FROM subscription_shares sh WHERE sh.subscription_id = subscription.id)"
+			}
+		}
+
+		// expr.Parse produces no other nodes.
+		return "FALSE"
+	}
+
+	condition := walk(node)
+	return condition, args
+}
+
+// normalizeCategory brings a category literal to the form categories are
+// stored in: lowercased, trimmed and with inner whitespace collapsed.
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.Join(strings.Fields(category), " "))
+}
+
+// likePattern turns a pattern where * stands for any characters and ? for
+// one into an ILIKE pattern, escaping the characters special to LIKE.
+func likePattern(pattern string) string {
+	var b strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '\\', '%', '_':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '*':
+			b.WriteByte('%')
+		case '?':
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
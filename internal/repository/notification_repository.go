@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"test_task/internal/database"
+	"test_task/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(cluster *database.Cluster) *NotificationRepository {
+	return &NotificationRepository{
+		db: cluster.Primary(),
+	}
+}
+
+// GetPreferences returns sql.ErrNoRows when the user has not set any.
+func (r *NotificationRepository) GetPreferences(ctx context.Context, userId uuid.UUID) (*entity.NotificationPreferences, error) {
+	query := `
+		SELECT user_id, channel, webhook_url, renewal_reminders, budget_alerts, reminder_days
+		FROM notification_preferences
+		WHERE user_id = $1
+	`
+
+	var p entity.NotificationPreferences
+
+	err := r.db.QueryRowContext(ctx, query, userId).Scan(
+		&p.UserId,
+		&p.Channel,
+		&p.WebhookURL,
+		&p.RenewalReminders,
+		&p.BudgetAlerts,
+		&p.ReminderDays,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (r *NotificationRepository) SetPreferences(ctx context.Context, p entity.NotificationPreferences) error {
+	query := `
+		INSERT INTO notification_preferences(user_id, channel, webhook_url, renewal_reminders, budget_alerts, reminder_days)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE
+		SET channel = EXCLUDED.channel,
+			webhook_url = EXCLUDED.webhook_url,
+			renewal_reminders = EXCLUDED.renewal_reminders,
+			budget_alerts = EXCLUDED.budget_alerts,
+			reminder_days = EXCLUDED.reminder_days
+	`
+
+	_, err := r.db.ExecContext(ctx, query, p.UserId, p.Channel, p.WebhookURL, p.RenewalReminders, p.BudgetAlerts, p.ReminderDays)
+	return err
+}
+
+// ClaimReminder marks a reminder as sent unless it already is, and reports
+// whether the caller claimed it and should send it.
+func (r *NotificationRepository) ClaimReminder(ctx context.Context, subscriptionId int, kind string, month entity.YearMonth) (bool, error) {
+	query := `
+		INSERT INTO sent_reminders(subscription_id, kind, month)
+		VALUES($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+
+	res, err := r.db.ExecContext(ctx, query, subscriptionId, kind, month)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	return rows > 0, err
+}
+
+// ReleaseReminder undoes a claim whose reminder could not be sent, so that a
+// later run retries it.
+func (r *NotificationRepository) ReleaseReminder(ctx context.Context, subscriptionId int, kind string, month entity.YearMonth) error {
+	query := `
+		DELETE FROM sent_reminders
+		WHERE subscription_id = $1 AND kind = $2 AND month = $3
+	`
+
+	_, err := r.db.ExecContext(ctx, query, subscriptionId, kind, month)
+	return err
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// EventsChannel is the LISTEN/NOTIFY channel announcing the ids of new
+// outbox events.
+const EventsChannel = "subscription_events"
+
+// addEvent writes an event to the outbox. Run in the transaction of the
+// change it describes, the event is committed or rolled back with it, and so
+// is its notification: NOTIFY is delivered on commit.
+func addEvent(ctx context.Context, q DBTX, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		WITH event AS (
+			INSERT INTO outbox_events(event_type, payload)
+			VALUES($1, $2)
+			RETURNING id
+		)
+		SELECT pg_notify($3, id::text) FROM event
+	`
+
+	_, err = q.ExecContext(ctx, query, eventType, payload, EventsChannel)
+	return err
+}
@@ -0,0 +1,363 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"test_task/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// monthsBetween is the SQL for the number of months from the month of date
+// a to the month of date b.
+func monthsBetween(a, b string) string {
+	return fmt.Sprintf("((date_part('year', %[2]s) - date_part('year', %[1]s)) * 12 + date_part('month', %[2]s) - date_part('month', %[1]s))::int", a, b)
+}
+
+// chargesQuery returns CTEs computing in SQL what Subscription.PriceIn and
+// Subscription.SplitIn compute in Go, for the subscriptions matching the
+// filter from filter.From (or their start) through filter.To:
+//   - charged has a row per subscription and active month, with the list
+//     price in effect and the amount charged;
+//   - parts has a row per subscription, charged month and paying user, with
+//     the part of the amount the user pays; months a subscription or a user
+//     pays nothing for are left out, so that they do not count it.
+//
+// Adjacent pauses are merged into runs, so that a renewal falling in a pause
+// is deferred to the first month after the run, as in Go.
+//
+// The Go methods are the reference: billing rules change there first and
+// are mirrored here, and TestChargesQueryMatchesGo compares the two on a
+// database given by TEST_DATABASE_URL.
+func chargesQuery(filter entity.SubscriptionFilter) (string, []interface{}) {
+	conditions, args := filterConditions(filter, nil)
+	args = append(args, filter.From, *filter.To)
+	from, to := len(args)-1, len(args)
+
+	return `
+		WITH subs AS (
+			SELECT id, user_id, service_name, price, start_date, end_date, promo_price, promo_months, split_rule,
+				CASE billing_period WHEN 'quarterly' THEN 3 WHEN 'yearly' THEN 12 ELSE 1 END AS period,
+				COALESCE(trial_end + interval '1 month', start_date)::date AS first_paid
+			FROM subscription
+			WHERE 1=1` + conditions + fmt.Sprintf(`
+		),
+		pause_starts AS (
+			SELECT p.subscription_id, p.start_month, p.end_month,
+				CASE WHEN (LAG(p.end_month) OVER w + interval '1 month')::date = p.start_month THEN 0 ELSE 1 END AS new_run
+			FROM subscription_pauses p
+			WHERE p.subscription_id IN (SELECT id FROM subs)
+			WINDOW w AS (PARTITION BY p.subscription_id ORDER BY p.start_month)
+		),
+		pause_runs AS (
+			SELECT subscription_id, start_month, end_month,
+				MIN(start_month) OVER (PARTITION BY subscription_id, run) AS run_start
+			FROM (
+				SELECT ps.*, SUM(new_run) OVER (PARTITION BY subscription_id ORDER BY start_month) AS run
+				FROM pause_starts ps
+			) r
+		),
+		months AS (
+			SELECT s.*, m::date AS month,
+				`+monthsBetween("s.first_paid", "m")+` AS paid_index,
+				EXISTS (
+					SELECT 1 FROM pause_runs pr
+					WHERE pr.subscription_id = s.id AND pr.start_month <= m
+						AND (pr.end_month IS NULL OR pr.end_month >= m)
+				) AS paused,
+				(
+					SELECT `+monthsBetween("s.first_paid", "pr.run_start")+` FROM pause_runs pr
+					WHERE pr.subscription_id = s.id AND pr.start_month <= m - interval '1 month'
+						AND (pr.end_month IS NULL OR pr.end_month >= m - interval '1 month')
+				) AS pause_index
+			FROM subs s
+			CROSS JOIN LATERAL generate_series(
+				GREATEST(s.start_date, COALESCE($%[1]d::date, s.start_date)),
+				LEAST(COALESCE(s.end_date, $%[2]d::date), $%[2]d::date),
+				interval '1 month'
+			) m
+		),
+		renewals AS (
+			SELECT mo.*,
+				CASE
+					WHEN mo.paid_index < 0 OR mo.paused THEN NULL
+					WHEN mo.paid_index %% mo.period = 0 THEN mo.paid_index
+					WHEN mo.pause_index IS NOT NULL
+						AND mo.paid_index - 1 - (mo.paid_index - 1) %% mo.period >= GREATEST(mo.pause_index, 0)
+						THEN mo.paid_index - 1 - (mo.paid_index - 1) %% mo.period
+				END AS due_index
+			FROM months mo
+		),
+		priced AS (
+			SELECT re.id, re.user_id, re.service_name, re.split_rule, re.month,
+				COALESCE((
+					SELECT c.price FROM subscription_price_changes c
+					WHERE c.subscription_id = re.id AND c.effective_month <= re.month
+					ORDER BY c.effective_month DESC
+					LIMIT 1
+				), re.price) AS list_price,
+				CASE
+					WHEN re.due_index IS NULL THEN 0
+					WHEN re.promo_price IS NOT NULL AND re.due_index < re.promo_months THEN re.promo_price
+				END AS offer_price
+			FROM renewals re
+		),
+		charged AS (
+			SELECT id, user_id, service_name, split_rule, month, list_price,
+				COALESCE(offer_price, list_price) AS amount
+			FROM priced
+		),
+		shared AS (
+			SELECT ch.id, ch.service_name, ch.month, sh.user_id,
+				CASE ch.split_rule
+					WHEN 'equal' THEN ch.amount / (COUNT(*) OVER w + 1)
+					WHEN 'percentage' THEN ch.amount * COALESCE(sh.percent, 0) / 100
+					WHEN 'fixed' THEN LEAST(COALESCE(sh.amount, 0),
+						GREATEST(ch.amount - (SUM(COALESCE(sh.amount, 0)) OVER ordered - COALESCE(sh.amount, 0)), 0))
+					ELSE 0
+				END AS part
+			FROM charged ch
+			JOIN subscription_shares sh ON sh.subscription_id = ch.id
+			WINDOW w AS (PARTITION BY ch.id, ch.month),
+				ordered AS (PARTITION BY ch.id, ch.month ORDER BY sh.user_id)
+		),
+		parts AS (
+			SELECT * FROM (
+				SELECT id, service_name, month, user_id, part FROM shared
+				UNION ALL
+				SELECT ch.id, ch.service_name, ch.month, ch.user_id,
+					ch.amount - COALESCE((SELECT SUM(s.part) FROM shared s WHERE s.id = ch.id AND s.month = ch.month), 0)
+				FROM charged ch
+			) p
+			WHERE part > 0
+		)
+	`, from, to), args
+}
+
+// userPartCondition restricts the parts to the ones the filtered user pays.
+func userPartCondition(filter entity.SubscriptionFilter, args []interface{}) (string, []interface{}) {
+	if filter.UserId == uuid.Nil {
+		return "", args
+	}
+
+	args = append(args, filter.UserId)
+	return fmt.Sprintf(" WHERE user_id = $%d", len(args)), args
+}
+
+// GetTopServicesBySpend ranks the services by what the subscriptions
+// matching the filter charged from filter.From through filter.To, which is
+// required. With filter.UserId set, only the user's share counts.
+func (r *SubscriptionRepository) GetTopServicesBySpend(ctx context.Context, filter entity.SubscriptionFilter, limit int) ([]entity.ServiceSpend, error) {
+	query, args := chargesQuery(filter)
+	where, args := userPartCondition(filter, args)
+	args = append(args, limit)
+	query += `
+		SELECT service_name, SUM(part), COUNT(DISTINCT id)
+		FROM parts` + where + fmt.Sprintf(`
+		GROUP BY service_name
+		ORDER BY SUM(part) DESC, service_name
+		LIMIT $%d
+	`, len(args))
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranking []entity.ServiceSpend
+	for rows.Next() {
+		var s entity.ServiceSpend
+		if err := rows.Scan(&s.ServiceName, &s.Total, &s.Subscriptions); err != nil {
+			return nil, err
+		}
+
+		ranking = append(ranking, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ranking, nil
+}
+
+// GetTopUsersBySpend ranks the users by what they paid for the
+// subscriptions matching the filter from filter.From through filter.To,
+// which is required, counting their share of shared subscriptions.
+func (r *SubscriptionRepository) GetTopUsersBySpend(ctx context.Context, filter entity.SubscriptionFilter, limit int) ([]entity.UserSpend, error) {
+	query, args := chargesQuery(filter)
+	where, args := userPartCondition(filter, args)
+	args = append(args, limit)
+	query += `
+		SELECT user_id, SUM(part), COUNT(DISTINCT id)
+		FROM parts` + where + fmt.Sprintf(`
+		GROUP BY user_id
+		ORDER BY SUM(part) DESC, user_id
+		LIMIT $%d
+	`, len(args))
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranking []entity.UserSpend
+	for rows.Next() {
+		var u entity.UserSpend
+		if err := rows.Scan(&u.UserId, &u.Total, &u.Subscriptions); err != nil {
+			return nil, err
+		}
+
+		ranking = append(ranking, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ranking, nil
+}
+
+// GetMostCommonServices ranks the services by how many subscriptions
+// matching the filter are active at least one month from filter.From
+// through filter.To.
+func (r *SubscriptionRepository) GetMostCommonServices(ctx context.Context, filter entity.SubscriptionFilter, limit int) ([]entity.ServicePopularity, error) {
+	conditions, args := filterConditions(filter, nil)
+	args = append(args, limit)
+	query := `
+		SELECT service_name, COUNT(*), COUNT(DISTINCT user_id)
+		FROM subscription
+		WHERE 1=1` + conditions + fmt.Sprintf(`
+		GROUP BY service_name
+		ORDER BY COUNT(*) DESC, service_name
+		LIMIT $%d
+	`, len(args))
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranking []entity.ServicePopularity
+	for rows.Next() {
+		var s entity.ServicePopularity
+		if err := rows.Scan(&s.ServiceName, &s.Subscriptions, &s.Users); err != nil {
+			return nil, err
+		}
+
+		ranking = append(ranking, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ranking, nil
+}
+
+// GetAveragePrices returns the average, lowest and highest list price of
+// each service over the months the subscriptions matching the filter were
+// active from filter.From through filter.To, which is required, most
+// expensive first.
+func (r *SubscriptionRepository) GetAveragePrices(ctx context.Context, filter entity.SubscriptionFilter, limit int) ([]entity.ServicePrice, error) {
+	query, args := chargesQuery(filter)
+	args = append(args, limit)
+	query += fmt.Sprintf(`
+		SELECT service_name, ROUND(AVG(list_price), 2)::float8, MIN(list_price), MAX(list_price), COUNT(DISTINCT id)
+		FROM charged
+		GROUP BY service_name
+		ORDER BY AVG(list_price) DESC, service_name
+		LIMIT $%d
+	`, len(args))
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []entity.ServicePrice
+	for rows.Next() {
+		var p entity.ServicePrice
+		if err := rows.Scan(&p.ServiceName, &p.Average, &p.Min, &p.Max, &p.Subscriptions); err != nil {
+			return nil, err
+		}
+
+		prices = append(prices, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prices, nil
+}
+
+// GetRetention counts, for the subscriptions matching the filter that
+// started from filter.From through filter.To, grouped by groupBy and start
+// month, how many are still active each month from their start through
+// until, at most maxOffset months after. Pauses do not end a subscription.
+// The rates of the retention points are left for the caller.
+func (r *SubscriptionRepository) GetRetention(ctx context.Context, filter entity.SubscriptionFilter, groupBy entity.GroupBy, until entity.YearMonth, maxOffset int) ([]entity.RetentionCohort, error) {
+	key := `''`
+	switch groupBy {
+	case entity.GroupByService:
+		key = `service_name`
+	case entity.GroupByCategory:
+		key = `COALESCE(category, '')`
+	}
+
+	from, to := filter.From, filter.To
+	filter.From, filter.To = nil, nil
+	conditions, args := filterConditions(filter, nil)
+	args = append(args, from, to, until, maxOffset)
+	n := len(args)
+
+	query := `
+		WITH cohorts AS (
+			SELECT ` + key + ` AS key, start_date AS cohort, end_date
+			FROM subscription
+			WHERE 1=1` + conditions + fmt.Sprintf(`
+				AND start_date BETWEEN $%d AND $%d
+		)
+		SELECT c.key, c.cohort, k, COUNT(*),
+			COUNT(*) FILTER (WHERE c.end_date IS NULL OR c.end_date >= (c.cohort + make_interval(months => k))::date)
+		FROM cohorts c
+		CROSS JOIN LATERAL generate_series(0, LEAST($%d,
+			((date_part('year', $%d::date) - date_part('year', c.cohort)) * 12
+				+ date_part('month', $%d::date) - date_part('month', c.cohort))::int
+		)) k
+		GROUP BY c.key, c.cohort, k
+		ORDER BY c.key, c.cohort, k
+	`, n-3, n-2, n, n-1, n-1)
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cohorts []entity.RetentionCohort
+	for rows.Next() {
+		var c entity.RetentionCohort
+		var p entity.RetentionPoint
+		if err := rows.Scan(&c.Key, &c.Cohort, &p.Offset, &c.Size, &p.Active); err != nil {
+			return nil, err
+		}
+
+		if last := len(cohorts) - 1; last < 0 || cohorts[last].Key != c.Key || cohorts[last].Cohort != c.Cohort {
+			cohorts = append(cohorts, c)
+		}
+		last := &cohorts[len(cohorts)-1]
+		last.Retention = append(last.Retention, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cohorts, nil
+}
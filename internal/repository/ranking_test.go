@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"test_task/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestChargesQueryMatchesGo checks chargesQuery against the reference
+// implementation, Subscription.SplitIn, on a migrated database given by
+// TEST_DATABASE_URL. Everything it writes is rolled back.
+func TestChargesQueryMatchesGo(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	exec := func(query string, args ...interface{}) {
+		t.Helper()
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+
+	payer, first, second := uuid.New(), uuid.New(), uuid.New()
+	for _, id := range []uuid.UUID{payer, first, second} {
+		exec(`INSERT INTO users(id, display_name) VALUES($1, $2)`, id, id.String())
+	}
+
+	subscribe := func(columns string, values ...interface{}) int {
+		t.Helper()
+		placeholders := ""
+		for i := range values {
+			placeholders += fmt.Sprintf(", $%d", i+2)
+		}
+
+		var id int
+		query := `INSERT INTO subscription(user_id, ` + columns + `) VALUES($1` + placeholders + `) RETURNING id`
+		if err := tx.QueryRowContext(ctx, query, append([]interface{}{payer}, values...)...).Scan(&id); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+
+		return id
+	}
+
+	// Trial, promotion and a price change.
+	id := subscribe(`service_name, price, start_date, trial_end, promo_price, promo_months`,
+		"Video", 100, "2025-01-01", "2025-02-01", 50, 2)
+	exec(`INSERT INTO subscription_price_changes(subscription_id, effective_month, price) VALUES($1, '2025-06-01', 150)`, id)
+
+	// Adjacent pauses deferring a quarterly renewal, then an end.
+	id = subscribe(`service_name, price, start_date, end_date, billing_period`,
+		"Cloud", 300, "2025-01-01", "2025-11-01", "quarterly")
+	exec(`INSERT INTO subscription_pauses(subscription_id, start_month, end_month) VALUES($1, '2025-03-01', '2025-03-01'), ($1, '2025-04-01', '2025-05-01')`, id)
+
+	// A yearly subscription paused until resumed.
+	id = subscribe(`service_name, price, start_date, billing_period`,
+		"Storage", 1200, "2024-06-01", "yearly")
+	exec(`INSERT INTO subscription_pauses(subscription_id, start_month) VALUES($1, '2025-09-01')`, id)
+
+	// Shared equally, by percentage and by amounts over the price.
+	id = subscribe(`service_name, price, start_date, split_rule`, "Music", 100, "2025-02-01", "equal")
+	exec(`INSERT INTO subscription_shares(subscription_id, user_id) VALUES($1, $2), ($1, $3)`, id, first, second)
+
+	id = subscribe(`service_name, price, start_date, split_rule, billing_period, promo_price, promo_months`,
+		"Games", 300, "2025-01-01", "percentage", "quarterly", 150, 4)
+	exec(`INSERT INTO subscription_shares(subscription_id, user_id, percent) VALUES($1, $2, 25), ($1, $3, NULL)`, id, first, second)
+	exec(`INSERT INTO subscription_pauses(subscription_id, start_month, end_month) VALUES($1, '2025-04-01', '2025-05-01')`, id)
+
+	id = subscribe(`service_name, price, start_date, split_rule`, "News", 100, "2025-01-01", "fixed")
+	exec(`INSERT INTO subscription_shares(subscription_id, user_id, amount) VALUES($1, $2, 30), ($1, $3, 90)`, id, first, second)
+
+	from, to := entity.NewYearMonth(2025, time.January), entity.NewYearMonth(2025, time.December)
+	filter := entity.SubscriptionFilter{UserId: payer, From: &from, To: &to}
+
+	conditions, args := filterConditions(filter, nil)
+	rows, err := tx.QueryContext(ctx, `SELECT `+subscriptionColumns+` FROM subscription WHERE 1=1`+conditions, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subs, err := scanSubscriptions(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type part struct {
+		id     int
+		month  entity.YearMonth
+		userId uuid.UUID
+	}
+
+	want := map[part]int{}
+	for _, sub := range subs {
+		for m := from; !m.After(to); m = m.AddMonths(1) {
+			for userId, amount := range sub.SplitIn(m) {
+				if amount > 0 {
+					want[part{sub.Id, m, userId}] = amount
+				}
+			}
+		}
+	}
+
+	query, args := chargesQuery(filter)
+	rows, err = tx.QueryContext(ctx, query+`SELECT id, month, user_id, part FROM parts`, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	got := map[part]int{}
+	for rows.Next() {
+		var p part
+		var amount int
+		if err := rows.Scan(&p.id, &p.month, &p.userId, &amount); err != nil {
+			t.Fatal(err)
+		}
+
+		got[p] = amount
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	for p, amount := range want {
+		if got[p] != amount {
+			t.Errorf("subscription %d, %s, user %s: SQL charges %d, Go %d", p.id, p.month, p.userId, got[p], amount)
+		}
+	}
+	for p, amount := range got {
+		if _, ok := want[p]; !ok {
+			t.Errorf("subscription %d, %s, user %s: SQL charges %d, Go nothing", p.id, p.month, p.userId, amount)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"test_task/internal/database"
+	"test_task/internal/entity"
+
+	"github.com/lib/pq"
+)
+
+const serviceColumns = `id, name, aliases, category, vendor_url, default_price`
+
+type ServiceRepository struct {
+	cluster *database.Cluster
+	q       DBTX
+	txOpts  TxOptions
+}
+
+func NewServiceRepository(cluster *database.Cluster, txOpts TxOptions) *ServiceRepository {
+	return &ServiceRepository{
+		cluster: cluster,
+		q:       cluster.Primary(),
+		txOpts:  txOpts,
+	}
+}
+
+// WithTx runs fn with a repository bound to a single transaction, see
+// SubscriptionRepository.WithTx.
+func (r *ServiceRepository) WithTx(ctx context.Context, fn func(repo *ServiceRepository) error) error {
+	if _, ok := r.q.(*sql.Tx); ok {
+		return fn(r)
+	}
+
+	return runInTx(ctx, r.cluster.Primary(), r.txOpts, func(tx *sql.Tx) error {
+		return fn(&ServiceRepository{
+			cluster: r.cluster,
+			q:       tx,
+			txOpts:  r.txOpts,
+		})
+	})
+}
+
+func (r *ServiceRepository) CreateService(ctx context.Context, e entity.Service) (int, error) {
+	query := `
+		INSERT INTO services(name, aliases, category, vendor_url, default_price)
+		VALUES($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	var id int
+
+	err := r.q.QueryRowContext(ctx, query, e.Name, pq.Array(e.Aliases), e.Category, e.VendorURL, e.DefaultPrice).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *ServiceRepository) GetServiceById(ctx context.Context, id int) (*entity.Service, error) {
+	query := `
+		SELECT ` + serviceColumns + `
+		FROM services
+		WHERE id = $1
+	`
+
+	svc, err := scanService(r.q.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	return &svc, nil
+}
+
+// FindServiceByName returns the catalog entry whose name or one of whose
+// aliases equals name ignoring case, or sql.ErrNoRows.
+func (r *ServiceRepository) FindServiceByName(ctx context.Context, name string) (*entity.Service, error) {
+	query := `
+		SELECT ` + serviceColumns + `
+		FROM services
+		WHERE lower(name) = lower($1)
+		   OR EXISTS (SELECT 1 FROM unnest(aliases) AS alias WHERE lower(alias) = lower($1))
+		ORDER BY lower(name) = lower($1) DESC, id
+		LIMIT 1
+	`
+
+	svc, err := scanService(r.q.QueryRowContext(ctx, query, name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &svc, nil
+}
+
+func (r *ServiceRepository) GetAllServices(ctx context.Context) ([]entity.Service, error) {
+	query := `
+		SELECT ` + serviceColumns + `
+		FROM services
+		ORDER BY name
+	`
+
+	rows, err := r.q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var services []entity.Service
+	for rows.Next() {
+		svc, err := scanService(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		services = append(services, svc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return services, nil
+}
+
+func (r *ServiceRepository) UpdateService(ctx context.Context, e entity.Service) error {
+	query := `
+		UPDATE services
+		SET name = $1, aliases = $2, category = $3, vendor_url = $4, default_price = $5
+		WHERE id = $6
+	`
+
+	res, err := r.q.ExecContext(ctx, query, e.Name, pq.Array(e.Aliases), e.Category, e.VendorURL, e.DefaultPrice, e.Id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *ServiceRepository) DeleteService(ctx context.Context, id int) error {
+	query := `
+		DELETE FROM services
+		WHERE id = $1
+	`
+
+	res, err := r.q.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ClaimNames reserves the lowercased name and aliases of the entry,
+// releasing those it no longer has. A name another entry holds fails with a
+// unique violation of service_names_pkey, also when that entry is still
+// being written by a concurrent transaction.
+func (r *ServiceRepository) ClaimNames(ctx context.Context, e entity.Service) error {
+	if _, err := r.q.ExecContext(ctx, `DELETE FROM service_names WHERE service_id = $1`, e.Id); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO service_names(name_key, service_id)
+		SELECT DISTINCT lower(n), $1 FROM unnest($2::text[]) AS n
+	`
+
+	_, err := r.q.ExecContext(ctx, query, e.Id, pq.Array(e.Names()))
+	return err
+}
+
+// LinkSubscriptions points the subscriptions already linked to the entry or
+// named like it and not linked elsewhere at the entry, renames them to its
+// canonical name and gives those without a category the entry's one. It
+// returns the subscriptions it changed.
+func (r *ServiceRepository) LinkSubscriptions(ctx context.Context, e entity.Service) ([]entity.Subscription, error) {
+	query := `
+		UPDATE subscription
+		SET service_id = $1, service_name = $2, category = COALESCE(category, $4)
+		WHERE (service_id = $1
+		   OR (service_id IS NULL AND lower(service_name) IN (SELECT lower(n) FROM unnest($3::text[]) AS n)))
+		  AND (service_id IS DISTINCT FROM $1 OR service_name <> $2 OR (category IS NULL AND $4::text IS NOT NULL))
+		RETURNING ` + subscriptionColumns
+
+	rows, err := r.q.QueryContext(ctx, query, e.Id, e.Name, pq.Array(e.Names()), e.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscriptions(rows)
+}
+
+// AddEvent writes a subscription event to the outbox; call it within WithTx.
+func (r *ServiceRepository) AddEvent(ctx context.Context, eventType string, sub *entity.Subscription) error {
+	return addEvent(ctx, r.q, eventType, sub)
+}
+
+func scanService(row rowScanner) (entity.Service, error) {
+	var svc entity.Service
+
+	err := row.Scan(
+		&svc.Id,
+		&svc.Name,
+		pq.Array(&svc.Aliases),
+		&svc.Category,
+		&svc.VendorURL,
+		&svc.DefaultPrice,
+	)
+
+	return svc, err
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"test_task/internal/database"
+	"test_task/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+type StatementRepository struct {
+	db *sql.DB
+}
+
+func NewStatementRepository(cluster *database.Cluster) *StatementRepository {
+	return &StatementRepository{
+		db: cluster.Primary(),
+	}
+}
+
+// CreateStatement stores a statement. Statements are never updated, so a
+// second statement for the same user and month is a unique violation.
+func (r *StatementRepository) CreateStatement(ctx context.Context, st entity.Statement) error {
+	content, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO statements(user_id, month, content, created_at)
+		VALUES($1, $2, $3, $4)
+	`
+
+	_, err = r.db.ExecContext(ctx, query, st.UserId, st.Month, content, st.GeneratedAt)
+	return err
+}
+
+func (r *StatementRepository) GetStatement(ctx context.Context, userId uuid.UUID, month entity.YearMonth) (*entity.Statement, error) {
+	query := `
+		SELECT content
+		FROM statements
+		WHERE user_id = $1 AND month = $2
+	`
+
+	var content []byte
+
+	if err := r.db.QueryRowContext(ctx, query, userId, month).Scan(&content); err != nil {
+		return nil, err
+	}
+
+	var st entity.Statement
+	if err := json.Unmarshal(content, &st); err != nil {
+		return nil, err
+	}
+
+	return &st, nil
+}
+
+// GetStatements lists the statements of a user, latest month first.
+func (r *StatementRepository) GetStatements(ctx context.Context, userId uuid.UUID) ([]entity.StatementSummary, error) {
+	query := `
+		SELECT month, (content->>'total')::int, created_at
+		FROM statements
+		WHERE user_id = $1
+		ORDER BY month DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []entity.StatementSummary
+	for rows.Next() {
+		var st entity.StatementSummary
+		if err := rows.Scan(&st.Month, &st.Total, &st.GeneratedAt); err != nil {
+			return nil, err
+		}
+
+		statements = append(statements, st)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return statements, nil
+}
@@ -3,32 +3,108 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"test_task/internal/database"
 	"test_task/internal/entity"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// subscriptionColumns is the column list scanned by scanSubscription. It
+// has to be selected from the subscription table without an alias.
+const subscriptionColumns = `id, service_name, price, user_id, start_date, end_date, service_id, category,
+	ARRAY(SELECT tag FROM subscription_tags WHERE subscription_id = subscription.id ORDER BY tag),
+	trial_end, promo_price, promo_months,
+	COALESCE((
+		SELECT json_agg(json_build_object('id', p.id, 'from', p.start_month, 'to', p.end_month) ORDER BY p.start_month)
+		FROM subscription_pauses p
+		WHERE p.subscription_id = subscription.id
+	), '[]'),
+	split_rule,
+	COALESCE((
+		SELECT json_agg(json_build_object('user_id', sh.user_id, 'percent', sh.percent, 'amount', sh.amount) ORDER BY sh.user_id)
+		FROM subscription_shares sh
+		WHERE sh.subscription_id = subscription.id
+	), '[]'),
+	billing_period,
+	COALESCE((
+		SELECT json_agg(json_build_object('from', c.effective_month, 'price', c.price) ORDER BY c.effective_month)
+		FROM subscription_price_changes c
+		WHERE c.subscription_id = subscription.id
+	), '[]')`
+
 type SubscriptionRepository struct {
-	db *sql.DB
+	cluster *database.Cluster
+	q       DBTX
+	txOpts  TxOptions
 }
 
-func NewSubscriptionRepository(db *sql.DB) *SubscriptionRepository {
+func NewSubscriptionRepository(cluster *database.Cluster, txOpts TxOptions) *SubscriptionRepository {
 	return &SubscriptionRepository{
-		db: db,
+		cluster: cluster,
+		q:       cluster.Primary(),
+		txOpts:  txOpts,
+	}
+}
+
+// WithTx runs fn with a repository bound to a single transaction, committed
+// when fn returns nil and rolled back otherwise. Called on a repository that
+// is already in a transaction, fn joins that transaction.
+func (r *SubscriptionRepository) WithTx(ctx context.Context, fn func(repo *SubscriptionRepository) error) error {
+	return r.withTx(ctx, r.txOpts, fn)
+}
+
+// WithReadCommittedTx is WithTx at read committed isolation whatever the
+// configured level, for transactions that take a lock and then must see what
+// concurrent transactions committed while they waited for it. At repeatable
+// read and above the snapshot is taken by the locking statement, before the
+// wait. A transaction that is joined keeps its level.
+func (r *SubscriptionRepository) WithReadCommittedTx(ctx context.Context, fn func(repo *SubscriptionRepository) error) error {
+	opts := r.txOpts
+	opts.Isolation = sql.LevelReadCommitted
+
+	return r.withTx(ctx, opts, fn)
+}
+
+func (r *SubscriptionRepository) withTx(ctx context.Context, opts TxOptions, fn func(repo *SubscriptionRepository) error) error {
+	if _, ok := r.q.(*sql.Tx); ok {
+		return fn(r)
 	}
+
+	return runInTx(ctx, r.cluster.Primary(), opts, func(tx *sql.Tx) error {
+		return fn(&SubscriptionRepository{
+			cluster: r.cluster,
+			q:       tx,
+			txOpts:  r.txOpts,
+		})
+	})
+}
+
+// reader returns where to run heavy read-only queries: a replica, unless the
+// repository is in a transaction or the request asked to read from the primary.
+func (r *SubscriptionRepository) reader(ctx context.Context) DBTX {
+	if _, ok := r.q.(*sql.Tx); ok {
+		return r.q
+	}
+
+	return r.cluster.Reader(ctx)
 }
 
 func (r *SubscriptionRepository) CreateSubscription(ctx context.Context, e entity.Subscription) (int, error) {
 	query := `
-		INSERT INTO subscription(service_name, price, user_id, start_date, end_date)
-		VALUES($1, $2, $3, $4, $5)
+		INSERT INTO subscription(service_name, price, user_id, start_date, end_date, service_id, category,
+			trial_end, promo_price, promo_months, split_rule, billing_period)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id
 		`
 
 	var id int
 
-	err := r.db.QueryRowContext(ctx, query, e.ServiceName, e.Price, e.UserId, e.StartDate, e.EndDate).Scan(&id)
+	err := r.q.QueryRowContext(ctx, query, e.ServiceName, e.Price, e.UserId, e.StartDate, e.EndDate, e.ServiceId, e.Category,
+		e.TrialEnd, e.PromoPrice, e.PromoMonths, e.SplitRule, e.BillingPeriod).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
@@ -38,22 +114,12 @@ func (r *SubscriptionRepository) CreateSubscription(ctx context.Context, e entit
 
 func (r *SubscriptionRepository) GetSubscriptionById(ctx context.Context, id int) (*entity.Subscription, error) {
 	query := `
-		SELECT id, service_name, price, user_id, formatted_start_date, formatted_end_date
+		SELECT ` + subscriptionColumns + `
 		FROM subscription
 		WHERE id = $1
 	`
 
-	var sub entity.Subscription
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&sub.Id,
-		&sub.ServiceName,
-		&sub.Price,
-		&sub.UserId,
-		&sub.StartDate,
-		&sub.EndDate,
-	)
-
+	sub, err := scanSubscription(r.q.QueryRowContext(ctx, query, id))
 	if err != nil {
 		return nil, err
 	}
@@ -61,42 +127,87 @@ func (r *SubscriptionRepository) GetSubscriptionById(ctx context.Context, id int
 	return &sub, nil
 }
 
-func (r *SubscriptionRepository) GetAllSubscriptions(ctx context.Context) ([]entity.Subscription, error) {
+// LockSubscription reads a subscription and locks it until the end of the
+// transaction; call it within WithTx.
+func (r *SubscriptionRepository) LockSubscription(ctx context.Context, id int) (*entity.Subscription, error) {
 	query := `
-		SELECT id, service_name, price, user_id, formatted_start_date, formatted_end_date
+		SELECT ` + subscriptionColumns + `
 		FROM subscription
+		WHERE id = $1
+		FOR UPDATE
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	sub, err := scanSubscription(r.q.QueryRowContext(ctx, query, id))
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var subs []entity.Subscription
-	for rows.Next() {
-		var sub entity.Subscription
-		err := rows.Scan(
-			&sub.Id,
-			&sub.ServiceName,
-			&sub.Price,
-			&sub.UserId,
-			&sub.StartDate,
-			&sub.EndDate,
-		)
+	return &sub, nil
+}
 
-		if err != nil {
-			return nil, err
-		}
+func (r *SubscriptionRepository) AddPause(ctx context.Context, subscriptionId int, p entity.Pause) error {
+	query := `
+		INSERT INTO subscription_pauses(subscription_id, start_month, end_month)
+		VALUES($1, $2, $3)
+	`
 
-		subs = append(subs, sub)
+	_, err := r.q.ExecContext(ctx, query, subscriptionId, p.From, p.To)
+	return err
+}
+
+// EndPause sets the last month of a pause, or deletes the pause when to is
+// before its first month.
+func (r *SubscriptionRepository) EndPause(ctx context.Context, p entity.Pause, to entity.YearMonth) error {
+	if to.Before(p.From) {
+		_, err := r.q.ExecContext(ctx, `DELETE FROM subscription_pauses WHERE id = $1`, p.Id)
+		return err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	_, err := r.q.ExecContext(ctx, `UPDATE subscription_pauses SET end_month = $1 WHERE id = $2`, to, p.Id)
+	return err
+}
+
+// EndSubscription sets the last month of a subscription, cutting its trial
+// and pauses short at that month; call it within WithTx.
+func (r *SubscriptionRepository) EndSubscription(ctx context.Context, id int, end entity.YearMonth) error {
+	query := `
+		UPDATE subscription
+		SET end_date = $1, trial_end = CASE WHEN trial_end > $1 THEN $1 ELSE trial_end END
+		WHERE id = $2
+	`
+
+	if _, err := r.q.ExecContext(ctx, query, end, id); err != nil {
+		return err
 	}
 
-	return subs, nil
+	_, err := r.q.ExecContext(ctx, `DELETE FROM subscription_pauses WHERE subscription_id = $1 AND start_month > $2`, id, end)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		UPDATE subscription_pauses
+		SET end_month = $1
+		WHERE subscription_id = $2 AND end_month > $1
+	`
+
+	_, err = r.q.ExecContext(ctx, query, end, id)
+	return err
+}
+
+// SetCancellation records why a subscription was cancelled, replacing an
+// earlier cancellation of the same subscription.
+func (r *SubscriptionRepository) SetCancellation(ctx context.Context, c entity.Cancellation) error {
+	query := `
+		INSERT INTO subscription_cancellations(subscription_id, user_id, service_name, service_id, price, end_month, reason, comment)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (subscription_id) DO UPDATE
+		SET price = EXCLUDED.price, end_month = EXCLUDED.end_month, reason = EXCLUDED.reason,
+			comment = EXCLUDED.comment, created_at = now()
+	`
+
+	_, err := r.q.ExecContext(ctx, query, c.SubscriptionId, c.UserId, c.ServiceName, c.ServiceId, c.Price, c.EndMonth, c.Reason, c.Comment)
+	return err
 }
 
 func (r *SubscriptionRepository) DeleteSubById(ctx context.Context, id int) error {
@@ -105,7 +216,7 @@ func (r *SubscriptionRepository) DeleteSubById(ctx context.Context, id int) erro
 		WHERE id = $1
 	`
 
-	res, err := r.db.ExecContext(ctx, query, id)
+	res, err := r.q.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -119,11 +230,13 @@ func (r *SubscriptionRepository) DeleteSubById(ctx context.Context, id int) erro
 func (r *SubscriptionRepository) UpdateSubById(ctx context.Context, e entity.Subscription) error {
 	query := `
 		UPDATE subscription 
-		SET service_name = $1, price = $2, user_id = $3, start_date = $4, end_date = $5
-		WHERE id = $6
+		SET service_name = $1, price = $2, user_id = $3, start_date = $4, end_date = $5, service_id = $6, category = $7,
+			trial_end = $8, promo_price = $9, promo_months = $10, split_rule = $11, billing_period = $12
+		WHERE id = $13
 	`
 
-	res, err := r.db.ExecContext(ctx, query, e.ServiceName, e.Price, e.UserId, e.StartDate, e.EndDate, e.Id)
+	res, err := r.q.ExecContext(ctx, query, e.ServiceName, e.Price, e.UserId, e.StartDate, e.EndDate, e.ServiceId, e.Category,
+		e.TrialEnd, e.PromoPrice, e.PromoMonths, e.SplitRule, e.BillingPeriod, e.Id)
 	if err != nil {
 		return err
 	}
@@ -138,45 +251,479 @@ func (r *SubscriptionRepository) UpdateSubById(ctx context.Context, e entity.Sub
 	return nil
 }
 
-func (r *SubscriptionRepository) GetTotalCost(ctx context.Context, userId uuid.UUID, serviceName string, fromDate string, toDate *string) (int, error) {
+// AddEvent writes a subscription event to the outbox; call it within WithTx.
+func (r *SubscriptionRepository) AddEvent(ctx context.Context, eventType string, sub *entity.Subscription) error {
+	return addEvent(ctx, r.q, eventType, sub)
+}
+
+// LockUserSubscriptions serializes the writes to the subscriptions of a user
+// until the end of the transaction. The statements after it see the writes
+// committed while it waited only at read committed isolation, so call it
+// within WithReadCommittedTx.
+func (r *SubscriptionRepository) LockUserSubscriptions(ctx context.Context, userId uuid.UUID) error {
+	_, err := r.q.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('subscriptions:' || $1::text, 0))`, userId)
+	return err
+}
+
+// sameServiceAndMonths matches the subscriptions b of the same user to the
+// same canonical service as a, with at least one month in common. Linked
+// subscriptions hold the canonical name, so the names of unlinked ones are
+// compared too.
+const sameServiceAndMonths = `
+	b.user_id = a.user_id
+	AND (b.service_id = a.service_id OR lower(b.service_name) = lower(a.service_name))
+	AND b.start_date <= COALESCE(a.end_date, 'infinity')
+	AND COALESCE(b.end_date, 'infinity') >= a.start_date
+`
+
+// GetOverlapping returns the ids of the other subscriptions of the same user
+// to the same canonical service that share a month with e.
+func (r *SubscriptionRepository) GetOverlapping(ctx context.Context, e entity.Subscription) ([]int, error) {
+	query := `
+		SELECT b.id
+		FROM (SELECT $1::uuid AS user_id, $2::int AS service_id, $3::text AS service_name, $4::date AS start_date, $5::date AS end_date) a
+		JOIN subscription b ON ` + sameServiceAndMonths + `
+		WHERE b.id <> $6
+		ORDER BY b.id
+	`
+
+	rows, err := r.q.QueryContext(ctx, query, e.UserId, e.ServiceId, e.ServiceName, e.StartDate, e.EndDate, e.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetOverlaps returns every pair of overlapping subscriptions, optionally
+// only those of one user.
+func (r *SubscriptionRepository) GetOverlaps(ctx context.Context, userId uuid.UUID) ([]entity.Overlap, error) {
 	query := `
-        SELECT COALESCE(SUM(price), 0)
-        FROM subscription
-        WHERE 1=1
-    `
-	args := []interface{}{}
-	argCounter := 1
+		SELECT a.user_id, a.service_name, a.service_id, a.id, b.id,
+			GREATEST(a.start_date, b.start_date),
+			NULLIF(LEAST(COALESCE(a.end_date, 'infinity'), COALESCE(b.end_date, 'infinity')), 'infinity')
+		FROM subscription a
+		JOIN subscription b ON b.id > a.id AND ` + sameServiceAndMonths + `
+		WHERE $1::uuid IS NULL OR a.user_id = $1
+		ORDER BY a.user_id, a.service_name, a.id, b.id
+	`
 
+	var user *uuid.UUID
 	if userId != uuid.Nil {
-		query += fmt.Sprintf(" AND user_id = $%d", argCounter)
+		user = &userId
+	}
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overlaps []entity.Overlap
+	for rows.Next() {
+		var o entity.Overlap
+		if err := rows.Scan(&o.UserId, &o.ServiceName, &o.ServiceId, &o.FirstId, &o.SecondId, &o.From, &o.To); err != nil {
+			return nil, err
+		}
+
+		overlaps = append(overlaps, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return overlaps, nil
+}
+
+// SetTags replaces the tags of a subscription.
+func (r *SubscriptionRepository) SetTags(ctx context.Context, id int, tags []string) error {
+	_, err := r.q.ExecContext(ctx, `DELETE FROM subscription_tags WHERE subscription_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO subscription_tags(subscription_id, tag)
+		SELECT $1, unnest($2::text[])
+	`
+
+	_, err = r.q.ExecContext(ctx, query, id, pq.Array(tags))
+	return err
+}
+
+// SetShares replaces the shares of a subscription.
+func (r *SubscriptionRepository) SetShares(ctx context.Context, id int, shares []entity.Share) error {
+	_, err := r.q.ExecContext(ctx, `DELETE FROM subscription_shares WHERE subscription_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO subscription_shares(subscription_id, user_id, percent, amount)
+		VALUES($1, $2, $3, $4)
+	`
+
+	for _, share := range shares {
+		if _, err := r.q.ExecContext(ctx, query, id, share.UserId, share.Percent, share.Amount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetPriceChanges replaces the scheduled price changes of a subscription.
+func (r *SubscriptionRepository) SetPriceChanges(ctx context.Context, id int, changes []entity.PriceChange) error {
+	_, err := r.q.ExecContext(ctx, `DELETE FROM subscription_price_changes WHERE subscription_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO subscription_price_changes(subscription_id, effective_month, price)
+		VALUES($1, $2, $3)
+	`
+
+	for _, c := range changes {
+		if _, err := r.q.ExecContext(ctx, query, id, c.From, c.Price); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetSubscriptionsForPeriod returns the subscriptions matching the filter
+// that are active at least one month between filter.From and filter.To.
+func (r *SubscriptionRepository) GetSubscriptionsForPeriod(ctx context.Context, filter entity.SubscriptionFilter) ([]entity.Subscription, error) {
+	conditions, args := filterConditions(filter, nil)
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscription
+		WHERE 1=1` + conditions
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscriptions(rows)
+}
+
+// ListSubscriptions returns a page of the subscriptions matching the filter
+// that are active at least one month between filter.From and filter.To, in id
+// order.
+func (r *SubscriptionRepository) ListSubscriptions(ctx context.Context, filter entity.SubscriptionFilter, page entity.Page) ([]entity.Subscription, error) {
+	conditions, args := filterConditions(filter, nil)
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscription
+		WHERE 1=1` + conditions + `
+		ORDER BY id`
+
+	pagination, args := pageClause(page, args)
+	rows, err := r.reader(ctx).QueryContext(ctx, query+pagination, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscriptions(rows)
+}
+
+// SearchSubscriptions returns a page of the subscriptions matching the filter
+// whose service name contains the text or resembles it, by trigram
+// similarity, most similar first.
+func (r *SubscriptionRepository) SearchSubscriptions(ctx context.Context, filter entity.SubscriptionFilter, text string, page entity.Page) ([]entity.Subscription, error) {
+	args := []interface{}{text, "%" + escapeLike(text) + "%"}
+	conditions, args := filterConditions(filter, args)
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscription
+		WHERE (service_name ILIKE $2 OR $1 <% service_name)` + conditions + `
+		ORDER BY word_similarity($1, service_name) DESC, similarity($1, service_name) DESC, id`
+
+	pagination, args := pageClause(page, args)
+	rows, err := r.reader(ctx).QueryContext(ctx, query+pagination, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscriptions(rows)
+}
+
+// GetServiceNameSuggestions returns a page of the distinct service names,
+// regardless of case, of the subscriptions matching the filter that start
+// with, contain or resemble the text, in that order. The spelling used by
+// most subscriptions represents a name.
+func (r *SubscriptionRepository) GetServiceNameSuggestions(ctx context.Context, filter entity.SubscriptionFilter, text string, page entity.Page) ([]string, error) {
+	args := []interface{}{text, escapeLike(text)}
+	conditions, args := filterConditions(filter, args)
+	query := `
+		SELECT mode() WITHIN GROUP (ORDER BY service_name)
+		FROM subscription
+		WHERE (service_name ILIKE '%' || $2 || '%' OR $1 <% service_name)` + conditions + `
+		GROUP BY lower(service_name)
+		ORDER BY lower(service_name) ILIKE $2 || '%' DESC, word_similarity($1, lower(service_name)) DESC, lower(service_name)`
+
+	pagination, args := pageClause(page, args)
+	query += pagination
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// GetTrialsEndingBetween returns the subscriptions whose trial ends from one
+// month through another and that stay active after it, optionally only those
+// of one user.
+func (r *SubscriptionRepository) GetTrialsEndingBetween(ctx context.Context, userId uuid.UUID, from, to entity.YearMonth) ([]entity.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscription
+		WHERE trial_end BETWEEN $1 AND $2
+			AND (end_date IS NULL OR end_date > trial_end)
+	`
+	args := []interface{}{from, to}
+
+	if userId != uuid.Nil {
+		query += " AND user_id = $3"
 		args = append(args, userId)
+	}
+
+	query += " ORDER BY trial_end, id"
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscriptions(rows)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row rowScanner) (entity.Subscription, error) {
+	var sub entity.Subscription
+	var pauses, shares, priceChanges []byte
+
+	err := row.Scan(
+		&sub.Id,
+		&sub.ServiceName,
+		&sub.Price,
+		&sub.UserId,
+		&sub.StartDate,
+		&sub.EndDate,
+		&sub.ServiceId,
+		&sub.Category,
+		pq.Array(&sub.Tags),
+		&sub.TrialEnd,
+		&sub.PromoPrice,
+		&sub.PromoMonths,
+		&pauses,
+		&sub.SplitRule,
+		&shares,
+		&sub.BillingPeriod,
+		&priceChanges,
+	)
+	if err != nil {
+		return sub, err
+	}
+
+	if err := json.Unmarshal(priceChanges, &sub.PriceChanges); err != nil {
+		return sub, err
+	}
+
+	if err := json.Unmarshal(pauses, &sub.Pauses); err != nil {
+		return sub, err
+	}
+
+	err = json.Unmarshal(shares, &sub.Shares)
+	return sub, err
+}
+
+// scanSubscriptions reads all rows selected with subscriptionColumns and
+// closes them.
+func scanSubscriptions(rows *sql.Rows) ([]entity.Subscription, error) {
+	defer rows.Close()
+
+	var subs []entity.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		subs = append(subs, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// GetChurn aggregates the cancellations ending from one month through
+// another by service and reason, optionally for a single user.
+func (r *SubscriptionRepository) GetChurn(ctx context.Context, userId uuid.UUID, from, to entity.YearMonth) ([]entity.ChurnGroup, error) {
+	query := `
+		SELECT service_name, reason, COUNT(*), COALESCE(SUM(price), 0)
+		FROM subscription_cancellations
+		WHERE end_month BETWEEN $1 AND $2
+			AND ($3::uuid IS NULL OR user_id = $3)
+		GROUP BY service_name, reason
+		ORDER BY COUNT(*) DESC, service_name, reason
+	`
+
+	var user *uuid.UUID
+	if userId != uuid.Nil {
+		user = &userId
+	}
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, from, to, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []entity.ChurnGroup
+	for rows.Next() {
+		var g entity.ChurnGroup
+		if err := rows.Scan(&g.ServiceName, &g.Reason, &g.Count, &g.LostMonthly); err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// pageClause returns the LIMIT and OFFSET of a page.
+func pageClause(page entity.Page, args []interface{}) (string, []interface{}) {
+	var clause string
+
+	if page.Limit > 0 {
+		args = append(args, page.Limit)
+		clause += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	if page.Offset > 0 {
+		args = append(args, page.Offset)
+		clause += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	return clause, args
+}
+
+// escapeLike escapes the characters special to LIKE patterns.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// filterConditions returns the conditions matching the filter, each
+// starting with AND, over the subscription table without an alias. Their
+// parameters are numbered after args and appended to them.
+func filterConditions(filter entity.SubscriptionFilter, args []interface{}) (string, []interface{}) {
+	var conditions string
+	argCounter := len(args) + 1
+
+	if filter.UserId != uuid.Nil && filter.IncludeShared {
+		conditions += fmt.Sprintf(" AND (user_id = $%[1]d OR EXISTS (SELECT 1 FROM subscription_shares sh WHERE sh.subscription_id = subscription.id AND sh.user_id = $%[1]d))", argCounter)
+		args = append(args, filter.UserId)
+		argCounter++
+	} else if filter.UserId != uuid.Nil {
+		conditions += fmt.Sprintf(" AND user_id = $%d", argCounter)
+		args = append(args, filter.UserId)
 		argCounter++
 	}
 
-	if serviceName != "" {
-		query += fmt.Sprintf(" AND service_name = $%d", argCounter)
-		args = append(args, serviceName)
+	if filter.Shared {
+		conditions += " AND EXISTS (SELECT 1 FROM subscription_shares sh WHERE sh.subscription_id = subscription.id)"
+	}
+
+	if filter.ServiceId != nil {
+		conditions += fmt.Sprintf(" AND service_id = $%d", argCounter)
+		args = append(args, *filter.ServiceId)
+		argCounter++
+	} else if filter.ServiceName != "" {
+		conditions += fmt.Sprintf(" AND service_name = $%d", argCounter)
+		args = append(args, filter.ServiceName)
+		argCounter++
+	}
+
+	if filter.Category != "" {
+		conditions += fmt.Sprintf(" AND category = $%d", argCounter)
+		args = append(args, filter.Category)
 		argCounter++
 	}
 
-	if fromDate != "" {
-		query += fmt.Sprintf(" AND start_date >= $%d", argCounter)
-		args = append(args, fromDate)
+	if filter.Tag != "" {
+		conditions += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM subscription_tags t WHERE t.subscription_id = subscription.id AND t.tag = $%d)", argCounter)
+		args = append(args, filter.Tag)
 		argCounter++
 	}
 
-	if toDate != nil {
-		query += fmt.Sprintf(" AND end_date <= $%d", argCounter)
-		args = append(args, toDate)
+	if filter.From != nil {
+		conditions += fmt.Sprintf(" AND (end_date IS NULL OR end_date >= $%d)", argCounter)
+		args = append(args, *filter.From)
 		argCounter++
 	}
 
-	var sum int
+	if filter.To != nil {
+		conditions += fmt.Sprintf(" AND start_date <= $%d", argCounter)
+		args = append(args, *filter.To)
+	}
 
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&sum)
-	if err != nil {
-		return 0, err
+	if filter.Expr != nil {
+		var condition string
+		condition, args = exprCondition(filter.Expr, args)
+		conditions += " AND " + condition
 	}
 
-	return sum, nil
+	return conditions, args
 }
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DBTX is the subset of *sql.DB and *sql.Tx used by repositories, so the same
+// repository code runs either directly on the pool or inside a transaction.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	// MaxRetries is how many times a transaction failed with a serialization
+	// error is run again before the error is returned.
+	MaxRetries int
+}
+
+func DefaultTxOptions() TxOptions {
+	return TxOptions{
+		Isolation:  sql.LevelReadCommitted,
+		MaxRetries: 3,
+	}
+}
+
+// ParseIsolationLevel accepts the level names used in SET TRANSACTION, with
+// spaces or underscores, e.g. "repeatable read" or "serializable".
+func ParseIsolationLevel(s string) (sql.IsolationLevel, error) {
+	switch strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "_", " ") {
+	case "", "read committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	default:
+		return 0, fmt.Errorf("unsupported isolation level %q", s)
+	}
+}
+
+// runInTx runs fn in a transaction and commits it, or rolls it back if fn
+// fails. Serialization failures restart the whole transaction.
+func runInTx(ctx context.Context, db *sql.DB, opts TxOptions, fn func(tx *sql.Tx) error) error {
+	for attempt := 0; ; attempt++ {
+		err := runInTxOnce(ctx, db, opts, fn)
+		if err == nil || !isSerializationFailure(err) || attempt >= opts.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * 10 * time.Millisecond):
+		}
+	}
+}
+
+func runInTxOnce(ctx context.Context, db *sql.DB, opts TxOptions, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: opts.Isolation})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func isSerializationFailure(err error) bool {
+	return hasCode(err, serializationFailure)
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"test_task/internal/database"
+	"test_task/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(cluster *database.Cluster) *UserRepository {
+	return &UserRepository{
+		db: cluster.Primary(),
+	}
+}
+
+func (r *UserRepository) CreateUser(ctx context.Context, u entity.User) error {
+	query := `
+		INSERT INTO users(id, display_name, email, timezone, default_currency, status)
+		VALUES($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, u.Id, u.DisplayName, u.Email, u.Timezone, u.DefaultCurrency, u.Status)
+	return err
+}
+
+func (r *UserRepository) GetUserById(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	query := `
+		SELECT id, display_name, email, timezone, default_currency, status
+		FROM users
+		WHERE id = $1
+	`
+
+	var u entity.User
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&u.Id,
+		&u.DisplayName,
+		&u.Email,
+		&u.Timezone,
+		&u.DefaultCurrency,
+		&u.Status,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+func (r *UserRepository) GetAllUsers(ctx context.Context) ([]entity.User, error) {
+	query := `
+		SELECT id, display_name, email, timezone, default_currency, status
+		FROM users
+		ORDER BY display_name, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []entity.User
+	for rows.Next() {
+		var u entity.User
+		err := rows.Scan(
+			&u.Id,
+			&u.DisplayName,
+			&u.Email,
+			&u.Timezone,
+			&u.DefaultCurrency,
+			&u.Status,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) UpdateUser(ctx context.Context, u entity.User) error {
+	query := `
+		UPDATE users
+		SET display_name = $1, email = $2, timezone = $3, default_currency = $4, status = $5
+		WHERE id = $6
+	`
+
+	res, err := r.db.ExecContext(ctx, query, u.DisplayName, u.Email, u.Timezone, u.DefaultCurrency, u.Status, u.Id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *UserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	query := `
+		DELETE FROM users
+		WHERE id = $1
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ReplaceCalendarTokenHash sets the hash of the calendar token of a user if
+// the current one is oldHash, empty for none, and reports whether it did.
+func (r *UserRepository) ReplaceCalendarTokenHash(ctx context.Context, id uuid.UUID, oldHash, hash string) (bool, error) {
+	query := `
+		UPDATE users
+		SET calendar_token_hash = $1
+		WHERE id = $2 AND calendar_token_hash IS NOT DISTINCT FROM NULLIF($3, '')
+	`
+
+	res, err := r.db.ExecContext(ctx, query, hash, id, oldHash)
+	if err != nil {
+		return false, err
+	}
+	if rows, _ := res.RowsAffected(); rows > 0 {
+		return true, nil
+	}
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, sql.ErrNoRows
+	}
+
+	return false, nil
+}
+
+// GetCalendarTokenHash returns the hash of the calendar token of a user,
+// empty when none was issued.
+func (r *UserRepository) GetCalendarTokenHash(ctx context.Context, id uuid.UUID) (string, error) {
+	var hash sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT calendar_token_hash FROM users WHERE id = $1`, id).Scan(&hash)
+	return hash.String, err
+}
@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"test_task/internal/database"
+	"test_task/internal/entity"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const webhookColumns = `id, url, secret, event_types, active, created_at`
+
+const deliveryColumns = `d.id, d.webhook_id, d.event_id, e.event_type, d.status, d.attempts,
+	d.response_status, d.last_error, d.next_attempt_at, d.delivered_at, d.created_at`
+
+type WebhookRepository struct {
+	db     *sql.DB
+	txOpts TxOptions
+}
+
+func NewWebhookRepository(cluster *database.Cluster, txOpts TxOptions) *WebhookRepository {
+	return &WebhookRepository{
+		db:     cluster.Primary(),
+		txOpts: txOpts,
+	}
+}
+
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, w entity.Webhook) (*entity.Webhook, error) {
+	query := `
+		INSERT INTO webhooks(url, secret, event_types, active)
+		VALUES($1, $2, $3, $4)
+		RETURNING ` + webhookColumns
+
+	created, err := scanWebhook(r.db.QueryRowContext(ctx, query, w.URL, w.Secret, pq.Array(w.EventTypes), w.Active))
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *WebhookRepository) GetWebhookById(ctx context.Context, id int) (*entity.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE id = $1
+	`
+
+	w, err := scanWebhook(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+func (r *WebhookRepository) GetAllWebhooks(ctx context.Context) ([]entity.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []entity.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// UpdateWebhook keeps the stored secret when w.Secret is empty.
+func (r *WebhookRepository) UpdateWebhook(ctx context.Context, w entity.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url = $1, secret = COALESCE(NULLIF($2, ''), secret), event_types = $3, active = $4
+		WHERE id = $5
+	`
+
+	res, err := r.db.ExecContext(ctx, query, w.URL, w.Secret, pq.Array(w.EventTypes), w.Active, w.Id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries to a webhook, optionally only
+// those with the given status.
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, webhookId int, status string, limit int) ([]entity.WebhookDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries d
+		JOIN outbox_events e ON e.id = d.event_id
+		WHERE d.webhook_id = $1 AND ($2::text = '' OR d.status = $2)
+		ORDER BY d.id DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, webhookId, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []entity.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// FanOutEvents turns up to limit undispatched outbox events into deliveries
+// to the active webhooks subscribed to them, and returns how many events it
+// dispatched.
+func (r *WebhookRepository) FanOutEvents(ctx context.Context, limit int) (int, error) {
+	var dispatched int
+
+	err := runInTx(ctx, r.db, r.txOpts, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT id
+			FROM outbox_events
+			WHERE dispatched_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		`, limit)
+		if err != nil {
+			return err
+		}
+
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		dispatched = len(ids)
+		if len(ids) == 0 {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries(webhook_id, event_id)
+			SELECT w.id, e.id
+			FROM outbox_events e
+			JOIN webhooks w ON w.active AND e.event_type = ANY(w.event_types)
+			WHERE e.id = ANY($1)
+			ON CONFLICT DO NOTHING
+		`, pq.Array(ids))
+		if err != nil {
+			return fmt.Errorf("create deliveries: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE outbox_events SET dispatched_at = now() WHERE id = ANY($1)`, pq.Array(ids))
+		return err
+	})
+
+	return dispatched, err
+}
+
+// GetDueDeliveries returns up to limit pending deliveries whose next attempt
+// is due, oldest first.
+func (r *WebhookRepository) GetDueDeliveries(ctx context.Context, limit int) ([]entity.PendingDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `,
+			w.url, w.secret, e.payload, e.created_at
+		FROM webhook_deliveries d
+		JOIN outbox_events e ON e.id = d.event_id
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= now()
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []entity.PendingDelivery
+	for rows.Next() {
+		var p entity.PendingDelivery
+		var payload []byte
+		d := &p.Delivery
+
+		err := rows.Scan(
+			&d.Id,
+			&d.WebhookId,
+			&d.EventId,
+			&d.EventType,
+			&d.Status,
+			&d.Attempts,
+			&d.ResponseStatus,
+			&d.LastError,
+			&d.NextAttemptAt,
+			&d.DeliveredAt,
+			&d.CreatedAt,
+			&p.Webhook.URL,
+			&p.Webhook.Secret,
+			&payload,
+			&p.Event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Webhook.Id = d.WebhookId
+		p.Event.Id = d.EventId
+		p.Event.Type = d.EventType
+		p.Event.Data = payload
+		pending = append(pending, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// RecordAttempt stores the outcome of a delivery attempt.
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, d entity.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_status = $3, last_error = $4,
+			next_attempt_at = COALESCE($5, next_attempt_at), delivered_at = $6
+		WHERE id = $7
+	`
+
+	_, err := r.db.ExecContext(ctx, query, d.Status, d.Attempts, d.ResponseStatus, d.LastError, d.NextAttemptAt, d.DeliveredAt, d.Id)
+	return err
+}
+
+func scanWebhook(row rowScanner) (entity.Webhook, error) {
+	var w entity.Webhook
+
+	err := row.Scan(
+		&w.Id,
+		&w.URL,
+		&w.Secret,
+		pq.Array(&w.EventTypes),
+		&w.Active,
+		&w.CreatedAt,
+	)
+
+	return w, err
+}
+
+func scanDelivery(row rowScanner) (entity.WebhookDelivery, error) {
+	var d entity.WebhookDelivery
+	var next time.Time
+
+	err := row.Scan(
+		&d.Id,
+		&d.WebhookId,
+		&d.EventId,
+		&d.EventType,
+		&d.Status,
+		&d.Attempts,
+		&d.ResponseStatus,
+		&d.LastError,
+		&next,
+		&d.DeliveredAt,
+		&d.CreatedAt,
+	)
+	if d.Status == entity.DeliveryStatusPending {
+		d.NextAttemptAt = &next
+	}
+
+	return d, err
+}
@@ -0,0 +1,93 @@
+// Package scheduler runs background jobs periodically. A job runs on one
+// server at a time: each run holds a PostgreSQL advisory lock, and servers
+// that cannot take it skip the run.
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"log/slog"
+	"time"
+)
+
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	db   *sql.DB
+	jobs []Job
+}
+
+// New creates a scheduler taking its locks in the database db.
+func New(db *sql.DB) *Scheduler {
+	return &Scheduler{
+		db: db,
+	}
+}
+
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job right away and then every job interval until ctx is
+// done.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.runLocked(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runLocked runs the job inside a transaction holding its advisory lock, so
+// that the lock is released with the transaction however the job ends.
+func (s *Scheduler) runLocked(ctx context.Context, job Job) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("Ошибка запуска задачи", "job", job.Name, "error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	err = tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, lockKey(job.Name)).Scan(&locked)
+	if err != nil {
+		slog.Error("Ошибка блокировки задачи", "job", job.Name, "error", err)
+		return
+	}
+	if !locked {
+		slog.Debug("Задача выполняется на другом сервере", "job", job.Name)
+		return
+	}
+
+	start := time.Now()
+	if err := job.Run(ctx); err != nil {
+		slog.Error("Ошибка выполнения задачи", "job", job.Name, "error", err)
+		return
+	}
+
+	slog.Info("Задача выполнена", "job", job.Name, "duration", time.Since(start))
+}
+
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("scheduler:" + name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"test_task/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// minPeerSubscriptions is how many subscriptions to a service are needed
+// before its prices or cancellations are compared.
+const minPeerSubscriptions = 3
+
+var severityRank = map[string]int{
+	entity.SeverityHigh:   0,
+	entity.SeverityMedium: 1,
+	entity.SeverityLow:    2,
+}
+
+// GetAnomalies looks for unusual spend in a month: spend jumps per user and
+// per service compared with the month before, prices far above the median
+// of the same service, and subscriptions still active long after most
+// subscriptions to the same service were cancelled. Services are compared
+// across all users; with filter.UserId set, only that user's findings are
+// returned and spend counts their share of shared subscriptions. The
+// service name, category and tag filters narrow the findings to the
+// matching subscriptions, still compared with all subscriptions to their
+// services. The month replaces the date filters.
+func (s *SubscriptionService) GetAnomalies(ctx context.Context, filter entity.SubscriptionFilter, month entity.YearMonth, opts entity.AnomalyOptions) (*entity.AnomalyReport, error) {
+	if opts.JumpPercent <= 0 {
+		return nil, invalidInput("jump percent should be positive")
+	}
+	if opts.PriceFactor <= 1 {
+		return nil, invalidInput("price factor should be greater than 1")
+	}
+	if opts.ForgottenMonths <= 0 {
+		return nil, invalidInput("forgotten months should be positive")
+	}
+	if filter.From != nil || filter.To != nil {
+		return nil, invalidInput("anomalies are checked in a single month, use month instead of from_date and to_date")
+	}
+
+	subs, err := s.repo.GetSubscriptionsForPeriod(ctx, entity.SubscriptionFilter{To: &month})
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := s.anomalyScope(ctx, filter, month)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &entity.AnomalyReport{Month: month}
+	report.Anomalies = append(report.Anomalies, spendJumps(subs, scope, filter.UserId, month, opts)...)
+
+	byService := map[string][]entity.Subscription{}
+	for _, sub := range subs {
+		key := serviceKey(sub)
+		byService[key] = append(byService[key], sub)
+	}
+	for _, peers := range byService {
+		report.Anomalies = append(report.Anomalies, priceOutliers(peers, scope, filter.UserId, month, opts)...)
+		report.Anomalies = append(report.Anomalies, forgotten(peers, scope, filter.UserId, month, opts)...)
+	}
+
+	if report.Anomalies == nil {
+		report.Anomalies = []entity.Anomaly{}
+	}
+
+	sort.SliceStable(report.Anomalies, func(i, j int) bool {
+		a, b := report.Anomalies[i], report.Anomalies[j]
+		if a.Severity != b.Severity {
+			return severityRank[a.Severity] < severityRank[b.Severity]
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Explanation < b.Explanation
+	})
+
+	return report, nil
+}
+
+// anomalyScope returns the ids of the subscriptions matching the service
+// name, category and tag filters, or nil without such filters.
+func (s *SubscriptionService) anomalyScope(ctx context.Context, filter entity.SubscriptionFilter, month entity.YearMonth) (map[int]bool, error) {
+	if filter.ServiceName == "" && filter.Category == "" && filter.Tag == "" {
+		return nil, nil
+	}
+
+	scopeFilter := entity.SubscriptionFilter{
+		ServiceName: filter.ServiceName,
+		Category:    filter.Category,
+		Tag:         filter.Tag,
+		To:          &month,
+	}
+	if err := s.resolveFilter(ctx, &scopeFilter); err != nil {
+		return nil, err
+	}
+
+	subs, err := s.repo.GetSubscriptionsForPeriod(ctx, scopeFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := map[int]bool{}
+	for _, sub := range subs {
+		scope[sub.Id] = true
+	}
+
+	return scope, nil
+}
+
+// inScope reports whether a subscription is among the ones anomalies are
+// reported for: all of them with a nil scope.
+func inScope(scope map[int]bool, sub entity.Subscription) bool {
+	return scope == nil || scope[sub.Id]
+}
+
+// spendJumps compares the spend of each user and of each service in a month
+// with the month before. Spend is counted in monthly equivalents, so that
+// the renewal of a quarterly or yearly subscription is no jump.
+func spendJumps(subs []entity.Subscription, scope map[int]bool, userId uuid.UUID, month entity.YearMonth, opts entity.AnomalyOptions) []entity.Anomaly {
+	prev := month.AddMonths(-1)
+	users := map[uuid.UUID][2]int{}
+	services := map[string][2]int{}
+	names := map[string]string{}
+	for _, sub := range subs {
+		if !inScope(scope, sub) {
+			continue
+		}
+
+		key := serviceKey(sub)
+		if _, ok := names[key]; !ok {
+			names[key] = sub.ServiceName
+		}
+
+		for i, m := range []entity.YearMonth{prev, month} {
+			for id, part := range sub.MonthlySplitIn(m) {
+				if userId != uuid.Nil && id != userId {
+					continue
+				}
+
+				u := users[id]
+				u[i] += part
+				users[id] = u
+
+				svc := services[key]
+				svc[i] += part
+				services[key] = svc
+			}
+		}
+	}
+
+	var anomalies []entity.Anomaly
+	for id, spend := range users {
+		if severity, ok := jumpSeverity(spend[0], spend[1], opts.JumpPercent); ok {
+			anomalies = append(anomalies, entity.Anomaly{
+				Kind:     entity.AnomalySpendJump,
+				Severity: severity,
+				UserId:   &id,
+				Current:  spend[1],
+				Baseline: spend[0],
+				Explanation: fmt.Sprintf("monthly spend of user %s rose from %d in %s to %d in %s (+%d%%)",
+					id, spend[0], prev, spend[1], month, percentIncrease(spend[0], spend[1])),
+			})
+		}
+	}
+
+	for key, spend := range services {
+		name := names[key]
+		if severity, ok := jumpSeverity(spend[0], spend[1], opts.JumpPercent); ok {
+			anomaly := entity.Anomaly{
+				Kind:        entity.AnomalySpendJump,
+				Severity:    severity,
+				ServiceName: name,
+				Current:     spend[1],
+				Baseline:    spend[0],
+				Explanation: fmt.Sprintf("monthly spend on %s rose from %d in %s to %d in %s (+%d%%)",
+					name, spend[0], prev, spend[1], month, percentIncrease(spend[0], spend[1])),
+			}
+			if userId != uuid.Nil {
+				anomaly.UserId = &userId
+			}
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+
+	return anomalies
+}
+
+// jumpSeverity rates an increase of spend from one month to the next, if it
+// reaches the threshold. Spend appearing from nothing is not a jump.
+func jumpSeverity(before, after, thresholdPercent int) (string, bool) {
+	if before <= 0 || after <= before {
+		return "", false
+	}
+
+	increase := percentIncrease(before, after)
+	switch {
+	case increase >= 3*thresholdPercent:
+		return entity.SeverityHigh, true
+	case increase >= 2*thresholdPercent:
+		return entity.SeverityMedium, true
+	case increase >= thresholdPercent:
+		return entity.SeverityLow, true
+	default:
+		return "", false
+	}
+}
+
+func percentIncrease(before, after int) int {
+	return (after - before) * 100 / before
+}
+
+// serviceKey identifies the service of a subscription: its catalog entry,
+// or its name regardless of case for services missing from the catalog.
+func serviceKey(sub entity.Subscription) string {
+	if sub.ServiceId != nil {
+		return fmt.Sprintf("id:%d", *sub.ServiceId)
+	}
+
+	return "name:" + strings.ToLower(sub.ServiceName)
+}
+
+// priceOutliers flags the subscriptions to one service priced far above
+// the median price of the service. Prices are compared as monthly
+// equivalents, whatever the billing period.
+func priceOutliers(peers []entity.Subscription, scope map[int]bool, userId uuid.UUID, month entity.YearMonth, opts entity.AnomalyOptions) []entity.Anomaly {
+	var active []entity.Subscription
+	var prices []int
+	for _, sub := range peers {
+		if sub.ActiveIn(month) {
+			active = append(active, sub)
+			prices = append(prices, sub.MonthlyPriceIn(month))
+		}
+	}
+
+	if len(active) < minPeerSubscriptions {
+		return nil
+	}
+
+	median := medianOf(prices)
+	if median <= 0 {
+		return nil
+	}
+
+	var anomalies []entity.Anomaly
+	for i, sub := range active {
+		ratio := float64(prices[i]) / float64(median)
+		if ratio < opts.PriceFactor || !inScope(scope, sub) || (userId != uuid.Nil && sub.UserId != userId) {
+			continue
+		}
+
+		severity := entity.SeverityMedium
+		if ratio >= 2*opts.PriceFactor {
+			severity = entity.SeverityHigh
+		}
+
+		anomalies = append(anomalies, entity.Anomaly{
+			Kind:           entity.AnomalyPriceOutlier,
+			Severity:       severity,
+			UserId:         &sub.UserId,
+			ServiceName:    sub.ServiceName,
+			SubscriptionId: &sub.Id,
+			Current:        prices[i],
+			Baseline:       median,
+			Explanation: fmt.Sprintf("subscription %d to %s costs %d a month, %.1f times the median monthly price %d of %d subscriptions",
+				sub.Id, sub.ServiceName, prices[i], ratio, median, len(active)),
+		})
+	}
+
+	return anomalies
+}
+
+// forgotten flags the subscriptions to one service that are still active
+// long after most subscriptions to it were cancelled. They are compared
+// with the median month the others ended.
+func forgotten(peers []entity.Subscription, scope map[int]bool, userId uuid.UUID, month entity.YearMonth, opts entity.AnomalyOptions) []entity.Anomaly {
+	var active []entity.Subscription
+	var ends []entity.YearMonth
+	for _, sub := range peers {
+		switch {
+		case sub.ActiveIn(month):
+			active = append(active, sub)
+		case sub.EndDate != nil:
+			ends = append(ends, *sub.EndDate)
+		}
+	}
+
+	if len(active) == 0 || len(active)+len(ends) < minPeerSubscriptions || len(ends) < len(active) {
+		return nil
+	}
+
+	sort.Slice(ends, func(i, j int) bool { return ends[i].Before(ends[j]) })
+	typicalEnd := ends[len(ends)/2]
+	gap := typicalEnd.MonthsUntil(month)
+	if gap < opts.ForgottenMonths {
+		return nil
+	}
+
+	severity := entity.SeverityLow
+	if gap >= 2*opts.ForgottenMonths {
+		severity = entity.SeverityMedium
+	}
+
+	var anomalies []entity.Anomaly
+	for _, sub := range active {
+		if sub.StartDate.After(typicalEnd) || !inScope(scope, sub) || (userId != uuid.Nil && sub.UserId != userId) {
+			continue
+		}
+
+		anomalies = append(anomalies, entity.Anomaly{
+			Kind:           entity.AnomalyForgotten,
+			Severity:       severity,
+			UserId:         &sub.UserId,
+			ServiceName:    sub.ServiceName,
+			SubscriptionId: &sub.Id,
+			Current:        sub.ListPriceIn(month),
+			Explanation: fmt.Sprintf("subscription %d to %s is still active %d months after %d of %d subscriptions to it typically ended in %s",
+				sub.Id, sub.ServiceName, gap, len(ends), len(ends)+len(active), typicalEnd),
+		})
+	}
+
+	return anomalies
+}
+
+// medianOf returns the median of the values, the lower one of the middle
+// two for an even count.
+func medianOf(values []int) int {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	return sorted[(len(sorted)-1)/2]
+}
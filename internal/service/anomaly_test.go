@@ -0,0 +1,62 @@
+package service
+
+import (
+	"test_task/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSpendJumps(t *testing.T) {
+	user := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	opts := entity.DefaultAnomalyOptions()
+	month := ym(2025, time.April)
+
+	tests := []struct {
+		name  string
+		subs  []entity.Subscription
+		scope map[int]bool
+		want  int
+	}{
+		{
+			name: "quarterly renewal",
+			subs: []entity.Subscription{
+				{Id: 1, ServiceName: "Music", Price: 300, StartDate: ym(2025, time.January), BillingPeriod: entity.BillingQuarterly},
+			},
+		},
+		{
+			name: "yearly renewal",
+			subs: []entity.Subscription{
+				{Id: 1, ServiceName: "Cloud", Price: 1200, StartDate: ym(2024, time.April), BillingPeriod: entity.BillingYearly},
+			},
+		},
+		{
+			name: "price rise",
+			subs: []entity.Subscription{
+				{Id: 1, ServiceName: "Video", Price: 100, StartDate: ym(2025, time.January), BillingPeriod: entity.BillingMonthly,
+					PriceChanges: []entity.PriceChange{{From: month, Price: 200}}},
+			},
+			// The user and the service.
+			want: 2,
+		},
+		{
+			name: "price rise out of scope",
+			subs: []entity.Subscription{
+				{Id: 1, ServiceName: "Video", Price: 100, StartDate: ym(2025, time.January), BillingPeriod: entity.BillingMonthly,
+					PriceChanges: []entity.PriceChange{{From: month, Price: 200}}},
+			},
+			scope: map[int]bool{2: true},
+		},
+	}
+
+	for _, tt := range tests {
+		for i := range tt.subs {
+			tt.subs[i].UserId = user
+		}
+
+		if got := spendJumps(tt.subs, tt.scope, uuid.Nil, month, opts); len(got) != tt.want {
+			t.Errorf("%s: %d jumps, want %d: %v", tt.name, len(got), tt.want, got)
+		}
+	}
+}
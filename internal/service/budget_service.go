@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"test_task/internal/entity"
+	"test_task/internal/notify"
+	"test_task/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type BudgetService struct {
+	repo       *repository.BudgetRepository
+	subService *SubscriptionService
+	notifier   notify.Notifier
+}
+
+func NewBudgetService(repo *repository.BudgetRepository, subService *SubscriptionService, notifier notify.Notifier) *BudgetService {
+	return &BudgetService{
+		repo:       repo,
+		subService: subService,
+		notifier:   notifier,
+	}
+}
+
+func (s *BudgetService) CreateBudget(ctx context.Context, b entity.Budget) (*entity.Budget, error) {
+	b = normalizeBudget(b)
+	if err := validateBudget(b); err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.CreateBudget(ctx, b)
+	if err != nil {
+		return nil, budgetWriteError(err)
+	}
+	b.Id = id
+
+	return &b, nil
+}
+
+func (s *BudgetService) GetBudgetById(ctx context.Context, id int) (*entity.Budget, error) {
+	if id <= 0 {
+		return nil, invalidInput("budget id is required")
+	}
+
+	return s.repo.GetBudgetById(ctx, id)
+}
+
+func (s *BudgetService) GetBudgets(ctx context.Context, userId uuid.UUID) ([]entity.Budget, error) {
+	return s.repo.GetBudgets(ctx, userId)
+}
+
+func (s *BudgetService) UpdateBudget(ctx context.Context, b entity.Budget) error {
+	if b.Id <= 0 {
+		return invalidInput("budget id is required")
+	}
+
+	b = normalizeBudget(b)
+	if err := validateBudget(b); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateBudget(ctx, b); err != nil {
+		return budgetWriteError(err)
+	}
+
+	return nil
+}
+
+func (s *BudgetService) DeleteBudget(ctx context.Context, id int) error {
+	if id <= 0 {
+		return invalidInput("budget id is required")
+	}
+
+	return s.repo.DeleteBudget(ctx, id)
+}
+
+// GetBudgetStatus computes the current and projected spend of the budget
+// period containing the current month, with the alerts raised so far. It has
+// no side effects: alerts are raised by EvaluateAll.
+func (s *BudgetService) GetBudgetStatus(ctx context.Context, id int) (*entity.BudgetStatus, error) {
+	b, err := s.GetBudgetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := s.StatusAt(ctx, *b, entity.CurrentYearMonth())
+	if err != nil {
+		return nil, err
+	}
+
+	status.Alerts, err = s.repo.GetAlerts(ctx, b.Id, status.PeriodStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// EvaluateAll computes the status of every budget and raises alerts for the
+// thresholds the spend has reached. The scheduler runs it periodically. A
+// budget failing to evaluate does not hold up the others; the errors are
+// returned together.
+func (s *BudgetService) EvaluateAll(ctx context.Context) error {
+	budgets, err := s.repo.GetBudgets(ctx, uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	now := entity.CurrentYearMonth()
+	for _, b := range budgets {
+		if err := s.raiseAlerts(ctx, b, now); err != nil {
+			slog.Error("Ошибка проверки бюджета", "budget_id", b.Id, "error", err)
+			errs = append(errs, fmt.Errorf("budget %d: %w", b.Id, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// StatusAt computes the status of the budget as of the given month, without
+// its alerts.
+func (s *BudgetService) StatusAt(ctx context.Context, b entity.Budget, month entity.YearMonth) (*entity.BudgetStatus, error) {
+	start, end := b.PeriodAt(month)
+
+	filter := b.Filter()
+	filter.From = &start
+	filter.To = &month
+	spent, err := s.subService.GetTotalCost(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.To = &end
+	projected, err := s.subService.GetTotalCost(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.BudgetStatus{
+		BudgetId:         b.Id,
+		PeriodStart:      start,
+		PeriodEnd:        end,
+		Amount:           b.Amount,
+		Spent:            spent,
+		Projected:        projected,
+		SpentPercent:     spent * 100 / b.Amount,
+		ProjectedPercent: projected * 100 / b.Amount,
+	}, nil
+}
+
+// raiseAlerts records an alert, and sends it once, for each threshold the
+// spend of the budget period containing the given month has reached.
+func (s *BudgetService) raiseAlerts(ctx context.Context, b entity.Budget, now entity.YearMonth) error {
+	status, err := s.StatusAt(ctx, b, now)
+	if err != nil {
+		return err
+	}
+
+	for _, threshold := range entity.BudgetThresholds {
+		if status.Spent*100 < threshold*b.Amount {
+			continue
+		}
+
+		alert := entity.BudgetAlert{
+			BudgetId:    b.Id,
+			PeriodStart: status.PeriodStart,
+			Threshold:   threshold,
+			Spent:       status.Spent,
+		}
+
+		created, err := s.repo.RecordAlert(ctx, alert)
+		if err != nil {
+			return err
+		}
+		if created {
+			s.notifyAlert(ctx, b, alert)
+		}
+	}
+
+	return nil
+}
+
+// notifyAlert sends an alert once it is recorded. A failed delivery is only
+// logged: the alert stays visible in the budget status.
+func (s *BudgetService) notifyAlert(ctx context.Context, b entity.Budget, alert entity.BudgetAlert) {
+	err := s.notifier.Notify(ctx, notify.Notification{
+		Kind:    notify.KindBudgetAlert,
+		UserId:  b.UserId,
+		Subject: fmt.Sprintf("Budget %q reached %d%%", b.Name, alert.Threshold),
+		Body: fmt.Sprintf("Spent %d of %d in the period starting %s.",
+			alert.Spent, b.Amount, alert.PeriodStart),
+	})
+	if err != nil {
+		slog.Error("Ошибка отправки уведомления о бюджете", "budget_id", b.Id, "error", err)
+	}
+}
+
+func normalizeBudget(b entity.Budget) entity.Budget {
+	b.Name = normalizeName(b.Name)
+
+	if b.Category != nil {
+		category := normalizeLabel(*b.Category)
+		b.Category = &category
+		if category == "" {
+			b.Category = nil
+		}
+	}
+
+	if b.ServiceName != nil {
+		name := normalizeName(*b.ServiceName)
+		b.ServiceName = &name
+		if name == "" {
+			b.ServiceName = nil
+		}
+	}
+
+	if b.UserId != nil && *b.UserId == uuid.Nil {
+		b.UserId = nil
+	}
+
+	return b
+}
+
+func validateBudget(b entity.Budget) error {
+	if b.Name == "" {
+		return invalidInput("budget name is required")
+	}
+
+	if b.Period != entity.BudgetPeriodMonthly && b.Period != entity.BudgetPeriodYearly {
+		return invalidInput("period should be monthly or yearly")
+	}
+
+	if b.Amount <= 0 {
+		return invalidInput("amount should be positive")
+	}
+
+	return nil
+}
+
+func budgetWriteError(err error) error {
+	switch repository.ViolatedConstraint(err) {
+	case "budgets_user_id_fkey":
+		return invalidInput("user does not exist")
+	case "budgets_category_fkey":
+		return invalidInput("unknown category")
+	default:
+		return err
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"test_task/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// maxCalendarMonths bounds how far ahead the calendar feed reaches.
+const maxCalendarMonths = 36
+
+// GetCalendarEvents returns the billing dates of the subscriptions a user
+// pays for or shares, and the ends of their subscriptions, from the current
+// month through the given number of months, in date order.
+func (s *SubscriptionService) GetCalendarEvents(ctx context.Context, userId uuid.UUID, months int) ([]entity.CalendarEvent, error) {
+	if months <= 0 || months > maxCalendarMonths {
+		return nil, invalidInput(fmt.Sprintf("months should be between 1 and %d", maxCalendarMonths))
+	}
+
+	from := entity.CurrentYearMonth()
+	to := from.AddMonths(months - 1)
+	subs, err := s.repo.GetSubscriptionsForPeriod(ctx, entity.SubscriptionFilter{
+		UserId:        userId,
+		IncludeShared: true,
+		From:          &from,
+		To:            &to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var events []entity.CalendarEvent
+	for month := from; !month.After(to); month = month.AddMonths(1) {
+		for _, sub := range subs {
+			if sub.RenewsIn(month) {
+				events = append(events, entity.CalendarEvent{
+					Kind:         entity.CalendarRenewal,
+					Subscription: sub,
+					Month:        month,
+					Amount:       sub.PriceIn(month),
+					Share:        sub.SplitIn(month)[userId],
+				})
+			}
+		}
+
+		// Subscriptions end on the last day of the month, after the
+		// renewals of the month.
+		for _, sub := range subs {
+			if sub.EndDate != nil && *sub.EndDate == month && sub.UserId == userId {
+				events = append(events, entity.CalendarEvent{
+					Kind:         entity.CalendarEnd,
+					Subscription: sub,
+					Month:        month,
+				})
+			}
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"strings"
+	"test_task/internal/entity"
+	"test_task/internal/repository"
+)
+
+type CatalogService struct {
+	repo *repository.ServiceRepository
+}
+
+func NewCatalogService(repo *repository.ServiceRepository) *CatalogService {
+	return &CatalogService{
+		repo: repo,
+	}
+}
+
+func (s *CatalogService) CreateService(ctx context.Context, e entity.Service) (*entity.Service, error) {
+	e = normalizeService(e)
+	if err := validateService(e); err != nil {
+		return nil, err
+	}
+
+	err := s.repo.WithTx(ctx, func(repo *repository.ServiceRepository) error {
+		if err := checkNamesFree(ctx, repo, e); err != nil {
+			return err
+		}
+
+		id, err := repo.CreateService(ctx, e)
+		if err != nil {
+			return err
+		}
+		e.Id = id
+
+		return claimAndLink(ctx, repo, e)
+	})
+	if err != nil {
+		return nil, catalogWriteError(err)
+	}
+
+	return &e, nil
+}
+
+func (s *CatalogService) GetServiceById(ctx context.Context, id int) (*entity.Service, error) {
+	if id <= 0 {
+		return nil, invalidInput("service id is required")
+	}
+
+	return s.repo.GetServiceById(ctx, id)
+}
+
+func (s *CatalogService) GetAllServices(ctx context.Context) ([]entity.Service, error) {
+	return s.repo.GetAllServices(ctx)
+}
+
+// UpdateService changes a catalog entry and relinks subscriptions, so
+// renaming it renames the subscriptions linked to it and a new alias picks up
+// subscriptions that used it.
+func (s *CatalogService) UpdateService(ctx context.Context, e entity.Service) error {
+	if e.Id <= 0 {
+		return invalidInput("service id is required")
+	}
+
+	e = normalizeService(e)
+	if err := validateService(e); err != nil {
+		return err
+	}
+
+	err := s.repo.WithTx(ctx, func(repo *repository.ServiceRepository) error {
+		if err := checkNamesFree(ctx, repo, e); err != nil {
+			return err
+		}
+
+		if err := repo.UpdateService(ctx, e); err != nil {
+			return err
+		}
+
+		return claimAndLink(ctx, repo, e)
+	})
+
+	return catalogWriteError(err)
+}
+
+// DeleteService removes a catalog entry; its subscriptions keep their names
+// and become unlinked.
+func (s *CatalogService) DeleteService(ctx context.Context, id int) error {
+	if id <= 0 {
+		return invalidInput("service id is required")
+	}
+
+	return s.repo.DeleteService(ctx, id)
+}
+
+// Resolve returns the catalog entry matching name case- and
+// alias-insensitively, or nil when the name is not in the catalog.
+func (s *CatalogService) Resolve(ctx context.Context, name string) (*entity.Service, error) {
+	svc, err := s.repo.FindServiceByName(ctx, normalizeName(name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return svc, err
+}
+
+// checkNamesFree rejects a name or alias that already resolves to another
+// catalog entry. It only gives a friendlier error: the names of an entry
+// being written concurrently are caught by claimAndLink.
+func checkNamesFree(ctx context.Context, repo *repository.ServiceRepository, e entity.Service) error {
+	for _, name := range e.Names() {
+		other, err := repo.FindServiceByName(ctx, name)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if other.Id != e.Id {
+			return conflict("name " + name + " already belongs to service " + other.Name)
+		}
+	}
+
+	return nil
+}
+
+// claimAndLink reserves the names of a written entry and relinks the
+// subscriptions to it, publishing an update event for each one it changed.
+func claimAndLink(ctx context.Context, repo *repository.ServiceRepository, e entity.Service) error {
+	if err := repo.ClaimNames(ctx, e); err != nil {
+		return err
+	}
+
+	subs, err := repo.LinkSubscriptions(ctx, e)
+	if err != nil {
+		return err
+	}
+
+	for i := range subs {
+		if err := repo.AddEvent(ctx, entity.EventSubscriptionUpdated, &subs[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// catalogWriteError turns the constraint violations a catalog write can hit
+// into conflicts and input errors.
+func catalogWriteError(err error) error {
+	switch {
+	case repository.ViolatedConstraint(err) == "service_names_pkey":
+		return conflict("name already belongs to another service")
+	case repository.IsUniqueViolation(err):
+		return conflict("service with this name already exists")
+	case repository.IsForeignKeyViolation(err):
+		return invalidInput("unknown category")
+	default:
+		return err
+	}
+}
+
+func normalizeService(e entity.Service) entity.Service {
+	e.Name = normalizeName(e.Name)
+
+	if e.Category != nil {
+		category := normalizeLabel(*e.Category)
+		e.Category = &category
+		if category == "" {
+			e.Category = nil
+		}
+	}
+
+	seen := map[string]bool{strings.ToLower(e.Name): true}
+	aliases := []string{}
+	for _, alias := range e.Aliases {
+		alias = normalizeName(alias)
+		if alias == "" || seen[strings.ToLower(alias)] {
+			continue
+		}
+		seen[strings.ToLower(alias)] = true
+		aliases = append(aliases, alias)
+	}
+	e.Aliases = aliases
+
+	return e
+}
+
+func validateService(e entity.Service) error {
+	if e.Name == "" {
+		return invalidInput("service name is required")
+	}
+
+	if e.DefaultPrice != nil && *e.DefaultPrice < 0 {
+		return invalidInput("default price should be non-negative")
+	}
+
+	if e.VendorURL != nil {
+		u, err := url.ParseRequestURI(*e.VendorURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return invalidInput("vendor url should be an absolute http or https url")
+		}
+	}
+
+	return nil
+}
+
+// normalizeName trims a service name and collapses inner whitespace.
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"test_task/internal/entity"
+	"test_task/internal/repository"
+	"unicode/utf8"
+)
+
+type CategoryService struct {
+	repo *repository.CategoryRepository
+}
+
+func NewCategoryService(repo *repository.CategoryRepository) *CategoryService {
+	return &CategoryService{
+		repo: repo,
+	}
+}
+
+func (s *CategoryService) CreateCategory(ctx context.Context, c entity.Category) (*entity.Category, error) {
+	c.Name = normalizeLabel(c.Name)
+	if err := validateLabel("category", c.Name); err != nil {
+		return nil, err
+	}
+
+	err := s.repo.CreateCategory(ctx, c)
+	if repository.IsUniqueViolation(err) {
+		return nil, conflict("category already exists")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (s *CategoryService) GetAllCategories(ctx context.Context) ([]entity.Category, error) {
+	return s.repo.GetAllCategories(ctx)
+}
+
+func (s *CategoryService) DeleteCategory(ctx context.Context, name string) error {
+	err := s.repo.DeleteCategory(ctx, normalizeLabel(name))
+	if repository.IsForeignKeyViolation(err) {
+		return conflict("category is still used by services, subscriptions or budgets")
+	}
+
+	return err
+}
+
+// normalizeLabel brings category names and tags to the lower-case,
+// single-spaced form they are stored and matched in.
+func normalizeLabel(label string) string {
+	return strings.ToLower(normalizeName(label))
+}
+
+func validateLabel(kind, label string) error {
+	if label == "" {
+		return invalidInput(kind + " should not be empty")
+	}
+
+	if utf8.RuneCountInString(label) > 64 {
+		return invalidInput(kind + " should be at most 64 characters long")
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidInput marks errors caused by bad client input rather than by a
+// failure, so handlers can answer them with 400 Bad Request.
+var ErrInvalidInput = errors.New("invalid input")
+
+func invalidInput(msg string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidInput, msg)
+}
+
+// ErrConflict marks requests that contradict data already stored, such as a
+// duplicate unique value or deleting a row other rows still refer to.
+var ErrConflict = errors.New("conflict")
+
+func conflict(msg string) error {
+	return fmt.Errorf("%w: %s", ErrConflict, msg)
+}
+
+// ErrForbidden marks requests lacking the credentials the change they ask for
+// requires, so handlers can answer them with 403 Forbidden.
+var ErrForbidden = errors.New("forbidden")
+
+func forbidden(msg string) error {
+	return fmt.Errorf("%w: %s", ErrForbidden, msg)
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"test_task/internal/entity"
+	"test_task/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	eventPageSize     = 500
+	eventStreamBuffer = 64
+	recentEventsSize  = 1024
+)
+
+// EventService streams subscription events to clients. Each server listens
+// for the events committed by any server and fans them out to its streams.
+type EventService struct {
+	repo      *repository.EventRepository
+	catalog   *CatalogService
+	retention time.Duration
+
+	mu      sync.Mutex
+	streams map[*eventStream]struct{}
+	// lastId is the highest event id published, and recent the ids of the
+	// latest events published, so that an event is published once although
+	// both its notification and a catch-up after a reconnect may bring it.
+	lastId int64
+	recent map[int64]bool
+	order  []int64
+}
+
+// eventStream is a client stream. A stream that does not keep up is dropped;
+// the client then resumes from its last event.
+type eventStream struct {
+	events chan entity.Event
+}
+
+// NewEventService creates a service keeping events in the log for the given
+// retention.
+func NewEventService(repo *repository.EventRepository, catalog *CatalogService, retention time.Duration) *EventService {
+	return &EventService{
+		repo:      repo,
+		catalog:   catalog,
+		retention: retention,
+		streams:   map[*eventStream]struct{}{},
+		recent:    map[int64]bool{},
+	}
+}
+
+// Start listens for events until ctx is done.
+func (s *EventService) Start(ctx context.Context) error {
+	latest, err := s.repo.LatestEventId(ctx)
+	if err != nil {
+		return err
+	}
+	s.lastId = latest
+
+	go func() {
+		for {
+			err := s.repo.Listen(ctx, func(id int64) {
+				s.publishId(ctx, id)
+			}, func() {
+				s.catchUp(ctx)
+			})
+			if ctx.Err() != nil {
+				return
+			}
+
+			slog.Error("Ошибка прослушивания событий", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			s.catchUp(ctx)
+		}
+	}()
+
+	return nil
+}
+
+// Subscribe streams the events matching the user and service name of the
+// filter. With lastEventId set, the events after it still in the log are
+// sent first. The channel is closed when ctx is done or the stream is
+// dropped.
+func (s *EventService) Subscribe(ctx context.Context, filter entity.SubscriptionFilter, lastEventId int64) (<-chan entity.Event, error) {
+	filter.ServiceName = normalizeName(filter.ServiceName)
+	if filter.ServiceName != "" {
+		svc, err := s.catalog.Resolve(ctx, filter.ServiceName)
+		if err != nil {
+			return nil, err
+		}
+		if svc != nil {
+			filter.ServiceId = &svc.Id
+		}
+	}
+
+	stream := &eventStream{events: make(chan entity.Event, eventStreamBuffer)}
+	s.mu.Lock()
+	s.streams[stream] = struct{}{}
+	s.mu.Unlock()
+
+	out := make(chan entity.Event)
+	go func() {
+		defer close(out)
+		defer s.unsubscribe(stream)
+
+		send := func(e entity.Event) bool {
+			select {
+			case out <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		sent := map[int64]bool{}
+		if lastEventId > 0 {
+			backlog, reset, err := s.backlog(ctx, lastEventId)
+			if err != nil {
+				slog.Error("Ошибка чтения журнала событий", "error", err)
+				return
+			}
+
+			if reset && !send(entity.Event{Type: entity.EventStreamReset, CreatedAt: time.Now()}) {
+				return
+			}
+
+			for _, e := range backlog {
+				sent[e.Id] = true
+				if matchesEvent(filter, e) && !send(e) {
+					return
+				}
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-stream.events:
+				if !ok {
+					return
+				}
+				if sent[e.Id] || !matchesEvent(filter, e) {
+					continue
+				}
+				if !send(e) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// PruneEvents drops the events older than the retention from the log.
+func (s *EventService) PruneEvents(ctx context.Context) error {
+	n, err := s.repo.PruneEvents(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Журнал событий очищен", "deleted", n)
+	return nil
+}
+
+// backlog returns the events after lastEventId, and whether some of them may
+// be missing because events after it were pruned from the log.
+func (s *EventService) backlog(ctx context.Context, lastEventId int64) ([]entity.Event, bool, error) {
+	var events []entity.Event
+	after := lastEventId
+	for {
+		page, err := s.repo.GetEventsAfter(ctx, after, eventPageSize)
+		if err != nil {
+			return nil, false, err
+		}
+
+		events = append(events, page...)
+		if len(page) < eventPageSize {
+			break
+		}
+		after = page[len(page)-1].Id
+	}
+
+	// Read after the events, so that a prune running meanwhile is accounted
+	// for.
+	prunedThrough, err := s.repo.PrunedThrough(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return events, prunedThrough > lastEventId, nil
+}
+
+func (s *EventService) publishId(ctx context.Context, id int64) {
+	e, err := s.repo.GetEvent(ctx, id)
+	if err != nil {
+		slog.Error("Ошибка чтения события", "id", id, "error", err)
+		return
+	}
+
+	s.publish(*e)
+}
+
+// catchUp publishes the events that may have been missed while not listening.
+func (s *EventService) catchUp(ctx context.Context) {
+	for {
+		s.mu.Lock()
+		after := s.lastId
+		s.mu.Unlock()
+
+		events, err := s.repo.GetEventsAfter(ctx, after, eventPageSize)
+		if err != nil {
+			slog.Error("Ошибка чтения журнала событий", "error", err)
+			return
+		}
+
+		for _, e := range events {
+			s.publish(e)
+		}
+
+		if len(events) < eventPageSize {
+			return
+		}
+	}
+}
+
+func (s *EventService) publish(e entity.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.recent[e.Id] {
+		return
+	}
+	s.recent[e.Id] = true
+	s.order = append(s.order, e.Id)
+	if len(s.order) > recentEventsSize {
+		delete(s.recent, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.lastId = max(s.lastId, e.Id)
+
+	for stream := range s.streams {
+		select {
+		case stream.events <- e:
+		default:
+			slog.Warn("Клиент не успевает получать события, поток закрыт")
+			delete(s.streams, stream)
+			close(stream.events)
+		}
+	}
+}
+
+func (s *EventService) unsubscribe(stream *eventStream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.streams[stream]; ok {
+		delete(s.streams, stream)
+		close(stream.events)
+	}
+}
+
+// matchesEvent reports whether the subscription carried by an event matches
+// the user and service of the filter.
+func matchesEvent(filter entity.SubscriptionFilter, e entity.Event) bool {
+	var sub entity.Subscription
+	if err := json.Unmarshal(e.Data, &sub); err != nil {
+		return false
+	}
+
+	if filter.UserId != uuid.Nil && sub.UserId != filter.UserId {
+		return false
+	}
+
+	if filter.ServiceId != nil {
+		return sub.ServiceId != nil && *sub.ServiceId == *filter.ServiceId
+	}
+
+	return filter.ServiceName == "" || strings.EqualFold(sub.ServiceName, filter.ServiceName)
+}
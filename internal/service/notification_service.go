@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/url"
+	"test_task/internal/entity"
+	"test_task/internal/netguard"
+	"test_task/internal/notify"
+	"test_task/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// NotificationService routes notifications to the backend each user prefers.
+// It is itself a notify.Notifier.
+type NotificationService struct {
+	repo           *repository.NotificationRepository
+	users          *repository.UserRepository
+	channels       map[string]notify.Notifier
+	defaultChannel string
+}
+
+// NewNotificationService creates a service delivering through the given
+// backends keyed by channel. The default channel must be one of them; it is
+// used for users without preferences, for administrators and whenever the
+// preferred channel cannot reach a user.
+func NewNotificationService(repo *repository.NotificationRepository, users *repository.UserRepository, channels map[string]notify.Notifier, defaultChannel string) *NotificationService {
+	return &NotificationService{
+		repo:           repo,
+		users:          users,
+		channels:       channels,
+		defaultChannel: defaultChannel,
+	}
+}
+
+// GetPreferences returns the preferences of a user, or the defaults when the
+// user has not set any.
+func (s *NotificationService) GetPreferences(ctx context.Context, userId uuid.UUID) (*entity.NotificationPreferences, error) {
+	if _, err := s.users.GetUserById(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	return s.preferences(ctx, userId)
+}
+
+func (s *NotificationService) SetPreferences(ctx context.Context, p entity.NotificationPreferences) error {
+	if err := s.validatePreferences(ctx, p); err != nil {
+		return err
+	}
+
+	if p.Channel != entity.NotificationChannelWebhook {
+		p.WebhookURL = nil
+	}
+
+	err := s.repo.SetPreferences(ctx, p)
+	if repository.ViolatedConstraint(err) == "notification_preferences_user_id_fkey" {
+		return sql.ErrNoRows
+	}
+
+	return err
+}
+
+// Notify delivers a notification through the channel its recipient prefers,
+// dropping kinds the recipient opted out of.
+func (s *NotificationService) Notify(ctx context.Context, n notify.Notification) error {
+	if n.UserId == nil {
+		return s.channels[s.defaultChannel].Notify(ctx, n)
+	}
+
+	user, err := s.users.GetUserById(ctx, *n.UserId)
+	if err != nil {
+		return err
+	}
+
+	prefs, err := s.preferences(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+
+	switch n.Kind {
+	case notify.KindBudgetAlert:
+		if !prefs.BudgetAlerts {
+			return nil
+		}
+	case notify.KindRenewalReminder, notify.KindEndingReminder:
+		if !prefs.RenewalReminders {
+			return nil
+		}
+	}
+
+	channel := prefs.Channel
+	switch channel {
+	case entity.NotificationChannelEmail:
+		if user.Email != nil {
+			n.To = *user.Email
+		}
+	case entity.NotificationChannelWebhook:
+		if prefs.WebhookURL != nil {
+			n.To = *prefs.WebhookURL
+		}
+	}
+
+	notifier, ok := s.channels[channel]
+	if !ok || (channel != entity.NotificationChannelLog && n.To == "") {
+		slog.Warn("Канал уведомлений недоступен, используется канал по умолчанию",
+			"user_id", user.Id, "channel", channel)
+		n.To = ""
+		notifier = s.channels[s.defaultChannel]
+	}
+
+	return notifier.Notify(ctx, n)
+}
+
+func (s *NotificationService) preferences(ctx context.Context, userId uuid.UUID) (*entity.NotificationPreferences, error) {
+	prefs, err := s.repo.GetPreferences(ctx, userId)
+	if err == sql.ErrNoRows {
+		return &entity.NotificationPreferences{
+			UserId:           userId,
+			Channel:          s.defaultChannel,
+			RenewalReminders: true,
+			BudgetAlerts:     true,
+		}, nil
+	}
+
+	return prefs, err
+}
+
+func (s *NotificationService) validatePreferences(ctx context.Context, p entity.NotificationPreferences) error {
+	switch p.Channel {
+	case entity.NotificationChannelLog, entity.NotificationChannelEmail, entity.NotificationChannelWebhook:
+	default:
+		return invalidInput("channel should be log, email or webhook")
+	}
+
+	if _, ok := s.channels[p.Channel]; !ok {
+		return invalidInput("channel " + p.Channel + " is not enabled on this server")
+	}
+
+	if p.Channel == entity.NotificationChannelWebhook {
+		if p.WebhookURL == nil {
+			return invalidInput("webhook_url is required for the webhook channel")
+		}
+
+		u, err := url.Parse(*p.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return invalidInput("webhook_url should be an http or https URL")
+		}
+
+		if err := netguard.CheckHost(ctx, u.Hostname()); err != nil {
+			return invalidInput("webhook_url " + err.Error())
+		}
+	}
+
+	if p.ReminderDays != nil && (*p.ReminderDays < 1 || *p.ReminderDays > 31) {
+		return invalidInput("reminder_days should be between 1 and 31")
+	}
+
+	return nil
+}
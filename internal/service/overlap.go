@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"test_task/internal/entity"
+	"test_task/internal/repository"
+)
+
+// OverlapPolicy decides what happens when a subscription is written that
+// shares months with another subscription of the same user to the same
+// canonical service.
+type OverlapPolicy string
+
+const (
+	// OverlapReject refuses the write with a conflict.
+	OverlapReject OverlapPolicy = "reject"
+	// OverlapWarn stores the subscription and reports the overlaps.
+	OverlapWarn OverlapPolicy = "warn"
+	// OverlapAllow stores the subscription without checking.
+	OverlapAllow OverlapPolicy = "allow"
+)
+
+// ParseOverlapPolicy accepts reject, warn or allow, warn being the default.
+func ParseOverlapPolicy(s string) (OverlapPolicy, error) {
+	switch p := OverlapPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return OverlapWarn, nil
+	case OverlapReject, OverlapWarn, OverlapAllow:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown overlap policy %q: expected reject, warn or allow", s)
+	}
+}
+
+// checkOverlaps applies the overlap policy to a subscription about to be
+// written and returns the ids of the subscriptions it overlaps. Concurrent
+// writes for the same user wait for each other, and each sees what the
+// previous one committed, as long as it is called within WithReadCommittedTx.
+func (s *SubscriptionService) checkOverlaps(ctx context.Context, repo *repository.SubscriptionRepository, e entity.Subscription) ([]int, error) {
+	if s.overlapPolicy == OverlapAllow {
+		return nil, nil
+	}
+
+	if err := repo.LockUserSubscriptions(ctx, e.UserId); err != nil {
+		return nil, err
+	}
+
+	ids, err := repo.GetOverlapping(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) > 0 && s.overlapPolicy == OverlapReject {
+		return nil, conflict("subscription overlaps subscriptions " + joinIds(ids))
+	}
+
+	return ids, nil
+}
+
+// GetOverlaps lists the pairs of overlapping subscriptions, optionally only
+// those of one user.
+func (s *SubscriptionService) GetOverlaps(ctx context.Context, filter entity.SubscriptionFilter) ([]entity.Overlap, error) {
+	overlaps, err := s.repo.GetOverlaps(ctx, filter.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if overlaps == nil {
+		overlaps = []entity.Overlap{}
+	}
+
+	return overlaps, nil
+}
+
+func joinIds(ids []int) string {
+	s := make([]string, 0, len(ids))
+	for _, id := range ids {
+		s = append(s, strconv.Itoa(id))
+	}
+
+	return strings.Join(s, ", ")
+}
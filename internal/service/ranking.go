@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"test_task/internal/entity"
+)
+
+const (
+	// maxRankingLimit bounds the length of the ranking reports.
+	maxRankingLimit = 100
+	// maxRetentionMonths bounds how long cohorts are followed.
+	maxRetentionMonths = 120
+)
+
+// GetTopServicesBySpend ranks the services by what the matching
+// subscriptions charged over the period of the filter, as GetTotalCost
+// counts it.
+func (s *SubscriptionService) GetTopServicesBySpend(ctx context.Context, filter entity.SubscriptionFilter, limit int) ([]entity.ServiceSpend, error) {
+	if err := s.prepareRanking(ctx, &filter, limit); err != nil {
+		return nil, err
+	}
+
+	ranking, err := s.repo.GetTopServicesBySpend(ctx, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if ranking == nil {
+		ranking = []entity.ServiceSpend{}
+	}
+
+	return ranking, nil
+}
+
+// GetTopUsersBySpend ranks the users by what they paid over the period of
+// the filter, counting their share of shared subscriptions.
+func (s *SubscriptionService) GetTopUsersBySpend(ctx context.Context, filter entity.SubscriptionFilter, limit int) ([]entity.UserSpend, error) {
+	if err := s.prepareRanking(ctx, &filter, limit); err != nil {
+		return nil, err
+	}
+
+	ranking, err := s.repo.GetTopUsersBySpend(ctx, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if ranking == nil {
+		ranking = []entity.UserSpend{}
+	}
+
+	return ranking, nil
+}
+
+// GetMostCommonServices ranks the services by how many matching
+// subscriptions are active over the period of the filter.
+func (s *SubscriptionService) GetMostCommonServices(ctx context.Context, filter entity.SubscriptionFilter, limit int) ([]entity.ServicePopularity, error) {
+	if err := s.prepareRanking(ctx, &filter, limit); err != nil {
+		return nil, err
+	}
+
+	ranking, err := s.repo.GetMostCommonServices(ctx, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if ranking == nil {
+		ranking = []entity.ServicePopularity{}
+	}
+
+	return ranking, nil
+}
+
+// GetAveragePrices returns the average list price of each service over the
+// months the matching subscriptions were active in the period of the filter.
+func (s *SubscriptionService) GetAveragePrices(ctx context.Context, filter entity.SubscriptionFilter, limit int) ([]entity.ServicePrice, error) {
+	if err := s.prepareRanking(ctx, &filter, limit); err != nil {
+		return nil, err
+	}
+
+	prices, err := s.repo.GetAveragePrices(ctx, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if prices == nil {
+		prices = []entity.ServicePrice{}
+	}
+
+	return prices, nil
+}
+
+// prepareRanking validates the limit and the filter of a ranking report the
+// way GetTotalCost does: the period ends with the current month by default.
+func (s *SubscriptionService) prepareRanking(ctx context.Context, filter *entity.SubscriptionFilter, limit int) error {
+	if limit <= 0 || limit > maxRankingLimit {
+		return invalidInput(fmt.Sprintf("limit should be between 1 and %d", maxRankingLimit))
+	}
+
+	if filter.To == nil {
+		to := entity.CurrentYearMonth()
+		filter.To = &to
+	}
+
+	if filter.From != nil && filter.From.After(*filter.To) {
+		return invalidInput("from date should not be after to date")
+	}
+
+	if err := s.resolveFilter(ctx, filter); err != nil {
+		return err
+	}
+	filter.IncludeShared = true
+
+	return nil
+}
+
+// GetRetention follows the matching subscriptions started from filter.From
+// through filter.To, by default the twelve months up to the current one,
+// grouped by start month and by service or category: for each month since
+// the start, up to months of them or the current month, it reports the
+// share still active according to their start and end dates.
+func (s *SubscriptionService) GetRetention(ctx context.Context, filter entity.SubscriptionFilter, groupBy entity.GroupBy, months int) (*entity.RetentionReport, error) {
+	if groupBy != entity.GroupByNone && groupBy != entity.GroupByService && groupBy != entity.GroupByCategory {
+		return nil, invalidInput("retention can be grouped by service or category only")
+	}
+
+	if months <= 0 || months > maxRetentionMonths {
+		return nil, invalidInput(fmt.Sprintf("months should be between 1 and %d", maxRetentionMonths))
+	}
+
+	current := entity.CurrentYearMonth()
+	if filter.To == nil || filter.To.After(current) {
+		filter.To = &current
+	}
+	if filter.From == nil {
+		from := filter.To.AddMonths(-11)
+		filter.From = &from
+	}
+
+	if filter.From.After(*filter.To) {
+		return nil, invalidInput("from date should not be after to date")
+	}
+
+	if err := s.resolveFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+
+	cohorts, err := s.repo.GetRetention(ctx, filter, groupBy, current, months-1)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &entity.RetentionReport{
+		GroupBy: groupBy,
+		From:    *filter.From,
+		To:      *filter.To,
+		Cohorts: []entity.RetentionCohort{},
+	}
+	for _, c := range cohorts {
+		for i, p := range c.Retention {
+			c.Retention[i].Rate = math.Round(float64(p.Active)/float64(c.Size)*10000) / 10000
+		}
+		report.Cohorts = append(report.Cohorts, c)
+	}
+
+	return report, nil
+}
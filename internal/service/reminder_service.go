@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"test_task/internal/entity"
+	"test_task/internal/notify"
+	"test_task/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// reminderRunTime is how long a run keeps sending reminders; the rest are
+// sent by the next run.
+const reminderRunTime = time.Minute
+
+// ReminderService reminds users of subscriptions about to renew or end.
+// Subscriptions renew on the first day of every month they are active in.
+type ReminderService struct {
+	subRepo       *repository.SubscriptionRepository
+	repo          *repository.NotificationRepository
+	notifications *NotificationService
+	window        time.Duration
+}
+
+// NewReminderService creates a service reminding window ahead of a renewal,
+// unless a user prefers otherwise.
+func NewReminderService(subRepo *repository.SubscriptionRepository, repo *repository.NotificationRepository, notifications *NotificationService, window time.Duration) *ReminderService {
+	return &ReminderService{
+		subRepo:       subRepo,
+		repo:          repo,
+		notifications: notifications,
+		window:        window,
+	}
+}
+
+// SendReminders reminds of the subscriptions active this month that renew or
+// end at the start of the next one, if that is within the reminder window.
+// Each reminder is claimed in the database before it is sent, so it goes out
+// once even when several servers run concurrently. No reminder is started
+// after reminderRunTime.
+func (s *ReminderService) SendReminders(ctx context.Context, now time.Time) error {
+	deadline := time.Now().Add(reminderRunTime)
+	current := entity.YearMonthOf(now)
+	next := current.AddMonths(1)
+	renewal := next.Time()
+
+	subs, err := s.subRepo.GetSubscriptionsForPeriod(ctx, entity.SubscriptionFilter{From: &current, To: &current})
+	if err != nil {
+		return err
+	}
+
+	prefs := map[uuid.UUID]*entity.NotificationPreferences{}
+	var errs []error
+	for _, sub := range subs {
+		if !time.Now().Before(deadline) {
+			slog.Warn("Время на отправку напоминаний истекло, остальные будут отправлены при следующем запуске")
+			break
+		}
+
+		p, ok := prefs[sub.UserId]
+		if !ok {
+			p, err = s.notifications.GetPreferences(ctx, sub.UserId)
+			if err == sql.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			prefs[sub.UserId] = p
+		}
+
+		window := s.window
+		if p.ReminderDays != nil {
+			window = time.Duration(*p.ReminderDays) * 24 * time.Hour
+		}
+
+		if !p.RenewalReminders || renewal.Sub(now) > window || (sub.ActiveIn(next) && !sub.RenewsIn(next)) {
+			continue
+		}
+
+		if err := s.remind(ctx, sub, next); err != nil {
+			errs = append(errs, fmt.Errorf("subscription %d: %w", sub.Id, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *ReminderService) remind(ctx context.Context, sub entity.Subscription, next entity.YearMonth) error {
+	n := notify.Notification{
+		Kind:    notify.KindRenewalReminder,
+		UserId:  &sub.UserId,
+		Subject: fmt.Sprintf("%s renews on %s", sub.ServiceName, next.Time().Format(time.DateOnly)),
+		Body: fmt.Sprintf("Your %s subscription will charge %d on %s.",
+			sub.ServiceName, sub.PriceIn(next), next.Time().Format(time.DateOnly)),
+	}
+
+	if sub.EndDate != nil && sub.EndDate.Before(next) {
+		n.Kind = notify.KindEndingReminder
+		n.Subject = fmt.Sprintf("%s ends on %s", sub.ServiceName, next.Time().Format(time.DateOnly))
+		n.Body = fmt.Sprintf("Your %s subscription ends after %s and will not renew.",
+			sub.ServiceName, sub.EndDate)
+	}
+
+	claimed, err := s.repo.ClaimReminder(ctx, sub.Id, n.Kind, next)
+	if err != nil || !claimed {
+		return err
+	}
+
+	if err := s.notifications.Notify(ctx, n); err != nil {
+		if releaseErr := s.repo.ReleaseReminder(ctx, sub.Id, n.Kind, next); releaseErr != nil {
+			slog.Error("Ошибка снятия отметки о напоминании", "subscription_id", sub.Id, "error", releaseErr)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"test_task/internal/entity"
+	"unicode/utf8"
+)
+
+const (
+	// maxPageLimit bounds the pages of the listings.
+	maxPageLimit = 100
+	// defaultSearchLimit is the page size of a search without a limit, and
+	// defaultSuggestLimit that of autocompletion.
+	defaultSearchLimit  = 20
+	defaultSuggestLimit = 10
+	// maxSearchLength is the length of the service_name column.
+	maxSearchLength = 256
+)
+
+// ListSubscriptions returns a page of the subscriptions matching the filter,
+// in id order. A page without a limit holds all of them.
+func (s *SubscriptionService) ListSubscriptions(ctx context.Context, filter entity.SubscriptionFilter, page entity.Page) ([]entity.Subscription, error) {
+	if err := validatePage(page); err != nil {
+		return nil, err
+	}
+
+	if err := s.resolveFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListSubscriptions(ctx, filter, page)
+}
+
+// SearchSubscriptions returns a page of the subscriptions matching the filter
+// whose service name contains or resembles the text, such as "netflx" for
+// Netflix, most similar first.
+func (s *SubscriptionService) SearchSubscriptions(ctx context.Context, filter entity.SubscriptionFilter, text string, page entity.Page) ([]entity.Subscription, error) {
+	text, err := searchText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.Limit == 0 {
+		page.Limit = defaultSearchLimit
+	}
+	if err := validatePage(page); err != nil {
+		return nil, err
+	}
+
+	if err := s.resolveFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+
+	return s.repo.SearchSubscriptions(ctx, filter, text, page)
+}
+
+// SuggestServiceNames returns a page of the distinct service names of the
+// subscriptions matching the filter for autocompletion: names starting with
+// the text first, then names containing or resembling it. Names differing
+// only in case are suggested once.
+func (s *SubscriptionService) SuggestServiceNames(ctx context.Context, filter entity.SubscriptionFilter, text string, page entity.Page) ([]string, error) {
+	text, err := searchText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.Limit == 0 {
+		page.Limit = defaultSuggestLimit
+	}
+	if err := validatePage(page); err != nil {
+		return nil, err
+	}
+
+	if err := s.resolveFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetServiceNameSuggestions(ctx, filter, text, page)
+}
+
+func searchText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", invalidInput("search text is required")
+	}
+
+	if utf8.RuneCountInString(text) > maxSearchLength {
+		return "", invalidInput(fmt.Sprintf("search text should be at most %d characters", maxSearchLength))
+	}
+
+	return text, nil
+}
+
+func validatePage(page entity.Page) error {
+	if page.Limit < 0 || page.Limit > maxPageLimit {
+		return invalidInput(fmt.Sprintf("limit should be between 1 and %d", maxPageLimit))
+	}
+
+	if page.Offset < 0 {
+		return invalidInput("offset should not be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"test_task/internal/entity"
+	"test_task/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StatementService struct {
+	repo          *repository.StatementRepository
+	userRepo      *repository.UserRepository
+	subService    *SubscriptionService
+	budgetService *BudgetService
+}
+
+func NewStatementService(repo *repository.StatementRepository, userRepo *repository.UserRepository, subService *SubscriptionService, budgetService *BudgetService) *StatementService {
+	return &StatementService{
+		repo:          repo,
+		userRepo:      userRepo,
+		subService:    subService,
+		budgetService: budgetService,
+	}
+}
+
+// GenerateStatement builds and stores the statement of a user for a month
+// that is over. A statement is generated once: later edits of subscriptions
+// and budgets do not change it. It reflects the data as it is when
+// generated, so edits made since the month closed show up in it; CloseMonth
+// stores the statements of each month as soon as it is over.
+func (s *StatementService) GenerateStatement(ctx context.Context, userId uuid.UUID, month entity.YearMonth) (*entity.Statement, error) {
+	if month.IsZero() {
+		return nil, invalidInput("month is required")
+	}
+
+	if !month.Before(entity.CurrentYearMonth()) {
+		return nil, invalidInput("statements can only be generated for past months")
+	}
+
+	user, err := s.userRepo.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.repo.GetStatement(ctx, userId, month)
+	if err == nil {
+		return nil, conflict("statement for " + month.String() + " already exists")
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	st, err := s.build(ctx, *user, month)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.CreateStatement(ctx, *st)
+	if repository.IsUniqueViolation(err) {
+		return nil, conflict("statement for " + month.String() + " already exists")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// CloseMonth stores the missing statements of the active users for the
+// month before the current one. The scheduler runs it periodically, so that
+// statements capture the data as of the month close rather than whenever
+// they are asked for. A user failing does not hold up the others.
+func (s *StatementService) CloseMonth(ctx context.Context) error {
+	month := entity.CurrentYearMonth().AddMonths(-1)
+
+	users, err := s.userRepo.GetAllUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, user := range users {
+		if user.Status != entity.UserStatusActive {
+			continue
+		}
+
+		if err := s.closeMonth(ctx, user, month); err != nil {
+			slog.Error("Ошибка формирования выписки", "user_id", user.Id, "month", month, "error", err)
+			errs = append(errs, fmt.Errorf("user %s: %w", user.Id, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *StatementService) closeMonth(ctx context.Context, user entity.User, month entity.YearMonth) error {
+	_, err := s.repo.GetStatement(ctx, user.Id, month)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	st, err := s.build(ctx, user, month)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.CreateStatement(ctx, *st)
+	if repository.IsUniqueViolation(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *StatementService) GetStatement(ctx context.Context, userId uuid.UUID, month entity.YearMonth) (*entity.Statement, error) {
+	if month.IsZero() {
+		return nil, invalidInput("month is required")
+	}
+
+	return s.repo.GetStatement(ctx, userId, month)
+}
+
+func (s *StatementService) GetStatements(ctx context.Context, userId uuid.UUID) ([]entity.StatementSummary, error) {
+	return s.repo.GetStatements(ctx, userId)
+}
+
+func (s *StatementService) build(ctx context.Context, user entity.User, month entity.YearMonth) (*entity.Statement, error) {
+	prev := month.AddMonths(-1)
+	subs, err := s.subService.GetSubscriptions(ctx, entity.SubscriptionFilter{
+		UserId:        user.Id,
+		IncludeShared: true,
+		From:          &prev,
+		To:            &month,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	st := entity.Statement{
+		UserId:      user.Id,
+		DisplayName: user.DisplayName,
+		Currency:    user.DefaultCurrency,
+		Month:       month,
+		Charges:     []entity.StatementCharge{},
+		Budgets:     []entity.StatementBudget{},
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	for _, sub := range subs {
+		st.PreviousTotal += sub.SplitIn(prev)[user.Id]
+
+		amount := sub.SplitIn(month)[user.Id]
+		if amount == 0 {
+			continue
+		}
+
+		st.Charges = append(st.Charges, entity.StatementCharge{
+			SubscriptionId: sub.Id,
+			ServiceName:    sub.ServiceName,
+			Category:       sub.Category,
+			Price:          sub.PriceIn(month),
+			Amount:         amount,
+			Shared:         len(sub.Shares) > 0,
+		})
+		st.Total += amount
+	}
+
+	sort.Slice(st.Charges, func(i, j int) bool {
+		a, b := st.Charges[i], st.Charges[j]
+		if a.ServiceName != b.ServiceName {
+			return a.ServiceName < b.ServiceName
+		}
+		return a.SubscriptionId < b.SubscriptionId
+	})
+
+	st.Change = st.Total - st.PreviousTotal
+	if st.PreviousTotal > 0 {
+		percent := st.Change * 100 / st.PreviousTotal
+		st.ChangePercent = &percent
+	}
+
+	budgets, err := s.budgetService.GetBudgets(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range budgets {
+		status, err := s.budgetService.StatusAt(ctx, b, month)
+		if err != nil {
+			return nil, err
+		}
+
+		st.Budgets = append(st.Budgets, entity.StatementBudget{
+			BudgetId:     b.Id,
+			Name:         b.Name,
+			Period:       b.Period,
+			PeriodStart:  status.PeriodStart,
+			PeriodEnd:    status.PeriodEnd,
+			Amount:       b.Amount,
+			Spent:        status.Spent,
+			SpentPercent: status.SpentPercent,
+		})
+	}
+
+	return &st, nil
+}
@@ -2,149 +2,983 @@ package service
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 	"test_task/internal/entity"
 	"test_task/internal/repository"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
 
+const (
+	// maxTrialDays bounds how far ahead trial conversions are looked up.
+	maxTrialDays = 366
+	// maxCancelComment bounds the free-text reason of a cancellation.
+	maxCancelComment = 1000
+	// maxForecastMonths bounds how far ahead spend is forecast.
+	maxForecastMonths = 60
+)
+
 type SubscriptionService struct {
-	repo *repository.SubscriptionRepository
+	repo          *repository.SubscriptionRepository
+	catalog       *CatalogService
+	overlapPolicy OverlapPolicy
 }
 
-func NewSubscriptionService(repo *repository.SubscriptionRepository) *SubscriptionService {
+func NewSubscriptionService(repo *repository.SubscriptionRepository, catalog *CatalogService, overlapPolicy OverlapPolicy) *SubscriptionService {
 	return &SubscriptionService{
-		repo: repo,
+		repo:          repo,
+		catalog:       catalog,
+		overlapPolicy: overlapPolicy,
 	}
 }
 
-func (s *SubscriptionService) CreateSubscription(ctx context.Context, e entity.Subscription) (int, error) {
-	if e.ServiceName == "" {
-		return 0, errors.New("service name is required")
+// CreateSubscription stores a subscription and returns its id, along with
+// the ids of the subscriptions it overlaps when the overlap policy warns.
+func (s *SubscriptionService) CreateSubscription(ctx context.Context, e entity.Subscription) (int, []int, error) {
+	if err := s.prepare(ctx, &e); err != nil {
+		return 0, nil, err
 	}
 
-	if e.Price < 0 {
-		return 0, errors.New("price should be non-negative")
-	}
+	var id int
+	var overlaps []int
+	err := s.repo.WithReadCommittedTx(ctx, func(repo *repository.SubscriptionRepository) error {
+		var err error
+		overlaps, err = s.checkOverlaps(ctx, repo, e)
+		if err != nil {
+			return err
+		}
 
-	err := isDateValid(&e.StartDate, e.EndDate)
-	if err != nil {
-		return 0, err
-	}
+		id, err = repo.CreateSubscription(ctx, e)
+		if err != nil {
+			return err
+		}
+
+		if err := repo.SetTags(ctx, id, e.Tags); err != nil {
+			return err
+		}
+
+		if err := repo.SetShares(ctx, id, e.Shares); err != nil {
+			return err
+		}
+
+		if err := repo.SetPriceChanges(ctx, id, e.PriceChanges); err != nil {
+			return err
+		}
 
-	id, err := s.repo.CreateSubscription(ctx, e)
+		_, err = addSubscriptionEvent(ctx, repo, entity.EventSubscriptionCreated, id)
+		return err
+	})
 	if err != nil {
-		return 0, err
+		return 0, nil, writeError(err)
 	}
 
-	return id, nil
+	return id, overlaps, nil
 }
 
 func (s *SubscriptionService) GetSubscriptionById(ctx context.Context, id int) (*entity.Subscription, error) {
 	if id <= 0 {
-		return nil, errors.New("subscription id is required")
+		return nil, invalidInput("subscription id is required")
 	}
 
 	return s.repo.GetSubscriptionById(ctx, id)
 }
 
-func (s *SubscriptionService) GetAllSubscriptions(ctx context.Context) ([]entity.Subscription, error) {
-	return s.repo.GetAllSubscriptions(ctx)
+// GetSubscriptions returns all the subscriptions ListSubscriptions pages
+// through.
+func (s *SubscriptionService) GetSubscriptions(ctx context.Context, filter entity.SubscriptionFilter) ([]entity.Subscription, error) {
+	return s.ListSubscriptions(ctx, filter, entity.Page{})
 }
 
 func (s *SubscriptionService) DeleteSubById(ctx context.Context, id int) error {
 	if id <= 0 {
-		return errors.New("subscription id is required")
+		return invalidInput("subscription id is required")
 	}
 
-	err := s.repo.DeleteSubById(ctx, id)
+	return s.repo.WithTx(ctx, func(repo *repository.SubscriptionRepository) error {
+		sub, err := repo.GetSubscriptionById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := repo.DeleteSubById(ctx, id); err != nil {
+			return err
+		}
+
+		return repo.AddEvent(ctx, entity.EventSubscriptionDeleted, sub)
+	})
+}
+
+// UpdateSubById replaces a subscription and returns the ids of the
+// subscriptions it overlaps when the overlap policy warns.
+func (s *SubscriptionService) UpdateSubById(ctx context.Context, e entity.Subscription) ([]int, error) {
+	if e.Id <= 0 {
+		return nil, invalidInput("subscription id is required")
+	}
+
+	if err := s.prepare(ctx, &e); err != nil {
+		return nil, err
+	}
+
+	var overlaps []int
+	err := s.repo.WithReadCommittedTx(ctx, func(repo *repository.SubscriptionRepository) error {
+		var err error
+		overlaps, err = s.checkOverlaps(ctx, repo, e)
+		if err != nil {
+			return err
+		}
+
+		if err := repo.UpdateSubById(ctx, e); err != nil {
+			return err
+		}
+
+		if err := repo.SetTags(ctx, e.Id, e.Tags); err != nil {
+			return err
+		}
+
+		if err := repo.SetShares(ctx, e.Id, e.Shares); err != nil {
+			return err
+		}
+
+		if err := repo.SetPriceChanges(ctx, e.Id, e.PriceChanges); err != nil {
+			return err
+		}
+
+		_, err = addSubscriptionEvent(ctx, repo, entity.EventSubscriptionUpdated, e.Id)
+		return err
+	})
 	if err != nil {
+		return nil, writeError(err)
+	}
+
+	return overlaps, nil
+}
+
+// PauseSubscription stops billing a subscription for the requested months
+// and returns the updated subscription.
+func (s *SubscriptionService) PauseSubscription(ctx context.Context, id int, req entity.PauseRequest) (*entity.Subscription, error) {
+	if id <= 0 {
+		return nil, invalidInput("subscription id is required")
+	}
+
+	pause := entity.Pause{From: entity.CurrentYearMonth(), To: req.To}
+	if req.From != nil {
+		pause.From = *req.From
+	}
+
+	var sub *entity.Subscription
+	err := s.repo.WithTx(ctx, func(repo *repository.SubscriptionRepository) error {
+		locked, err := repo.LockSubscription(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := validatePause(*locked, pause); err != nil {
+			return err
+		}
+
+		if err := repo.AddPause(ctx, id, pause); err != nil {
+			return err
+		}
+
+		sub, err = addSubscriptionEvent(ctx, repo, entity.EventSubscriptionUpdated, id)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return sub, nil
 }
 
-func (s *SubscriptionService) UpdateSubById(ctx context.Context, e entity.Subscription) error {
-	if e.Id <= 0 {
-		return errors.New("subscription id is required")
+// ResumeSubscription ends the open pause of a subscription so that it is
+// billed again from the requested month, and returns the updated
+// subscription.
+func (s *SubscriptionService) ResumeSubscription(ctx context.Context, id int, req entity.ResumeRequest) (*entity.Subscription, error) {
+	if id <= 0 {
+		return nil, invalidInput("subscription id is required")
 	}
 
-	if e.ServiceName == "" {
-		return errors.New("service name is required")
+	month := entity.CurrentYearMonth()
+	if req.Month != nil {
+		month = *req.Month
+	}
+
+	var sub *entity.Subscription
+	err := s.repo.WithTx(ctx, func(repo *repository.SubscriptionRepository) error {
+		locked, err := repo.LockSubscription(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		open := locked.OpenPause()
+		if open == nil {
+			return conflict("subscription is not paused")
+		}
+
+		if err := validateResume(*locked, *open, entity.CurrentYearMonth(), month); err != nil {
+			return err
+		}
+
+		if err := repo.EndPause(ctx, *open, month.AddMonths(-1)); err != nil {
+			return err
+		}
+
+		sub, err = addSubscriptionEvent(ctx, repo, entity.EventSubscriptionUpdated, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// CancelSubscription ends a subscription with the requested month, by
+// default the end of the current billing period, records the reason and
+// returns the updated subscription.
+func (s *SubscriptionService) CancelSubscription(ctx context.Context, id int, req entity.CancelRequest) (*entity.Subscription, error) {
+	if id <= 0 {
+		return nil, invalidInput("subscription id is required")
+	}
+
+	if !slices.Contains(entity.CancelReasons, req.Reason) {
+		return nil, invalidInput("reason should be one of " + strings.Join(entity.CancelReasons, ", "))
+	}
+
+	if req.Comment != nil {
+		comment := strings.TrimSpace(*req.Comment)
+		if utf8.RuneCountInString(comment) > maxCancelComment {
+			return nil, invalidInput(fmt.Sprintf("comment should be at most %d characters", maxCancelComment))
+		}
+		req.Comment = &comment
+		if comment == "" {
+			req.Comment = nil
+		}
+	}
+
+	if req.Reason == entity.CancelReasonOther && req.Comment == nil {
+		return nil, invalidInput("comment is required for reason other")
+	}
+
+	var sub *entity.Subscription
+	err := s.repo.WithTx(ctx, func(repo *repository.SubscriptionRepository) error {
+		locked, err := repo.LockSubscription(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		current := entity.CurrentYearMonth()
+		month := locked.PeriodEnd(current)
+		if req.EffectiveMonth != nil {
+			month = *req.EffectiveMonth
+		}
+
+		if err := validateCancel(*locked, current, month); err != nil {
+			return err
+		}
+
+		if err := repo.EndSubscription(ctx, id, month); err != nil {
+			return err
+		}
+
+		err = repo.SetCancellation(ctx, entity.Cancellation{
+			SubscriptionId: &id,
+			UserId:         locked.UserId,
+			ServiceName:    locked.ServiceName,
+			ServiceId:      locked.ServiceId,
+			Price:          locked.ListPriceIn(month),
+			EndMonth:       month,
+			Reason:         req.Reason,
+			Comment:        req.Comment,
+		})
+		if err != nil {
+			return err
+		}
+
+		sub, err = addSubscriptionEvent(ctx, repo, entity.EventSubscriptionUpdated, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// GetChurnReport aggregates the cancellations of the matching user ending
+// from filter.From through filter.To by service and reason. The period
+// defaults to the twelve months up to the current one.
+func (s *SubscriptionService) GetChurnReport(ctx context.Context, filter entity.SubscriptionFilter) (*entity.ChurnReport, error) {
+	to := entity.CurrentYearMonth()
+	if filter.To != nil {
+		to = *filter.To
+	}
+	from := to.AddMonths(-11)
+	if filter.From != nil {
+		from = *filter.From
+	}
+
+	if to.Before(from) {
+		return nil, invalidInput("from date should not be after to date")
+	}
+
+	groups, err := s.repo.GetChurn(ctx, filter.UserId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &entity.ChurnReport{
+		From:   from,
+		To:     to,
+		Groups: []entity.ChurnGroup{},
+	}
+	for _, g := range groups {
+		report.Total += g.Count
+		report.LostMonthly += g.LostMonthly
+		report.Groups = append(report.Groups, g)
+	}
+
+	return report, nil
+}
+
+// GetTotalCost sums what the matching subscriptions charge from filter.From
+// through filter.To. Without filter.To the period ends with the current month.
+// With filter.UserId set, only the user's share of shared subscriptions
+// counts.
+func (s *SubscriptionService) GetTotalCost(ctx context.Context, filter entity.SubscriptionFilter) (int, error) {
+	total, err := s.GetTotalCostGrouped(ctx, filter, entity.CostBasisCharges, entity.GroupByNone)
+	if err != nil {
+		return 0, err
+	}
+
+	return total.Total, nil
+}
+
+// GetTotalCostGrouped totals the matching subscriptions on a basis, as
+// GetTotalCost does for CostBasisCharges, and breaks the total down by
+// groupBy, largest groups first.
+func (s *SubscriptionService) GetTotalCostGrouped(ctx context.Context, filter entity.SubscriptionFilter, basis entity.CostBasis, groupBy entity.GroupBy) (entity.TotalCost, error) {
+	if basis == entity.CostBasisPrice {
+		return s.getPriceTotal(ctx, filter, groupBy)
+	}
+
+	if filter.To == nil {
+		to := entity.CurrentYearMonth()
+		filter.To = &to
+	}
+
+	if filter.From != nil && filter.From.After(*filter.To) {
+		return entity.TotalCost{}, invalidInput("from date should not be after to date")
+	}
+
+	if err := s.resolveFilter(ctx, &filter); err != nil {
+		return entity.TotalCost{}, err
+	}
+	filter.IncludeShared = true
+
+	subs, err := s.repo.GetSubscriptionsForPeriod(ctx, filter)
+	if err != nil {
+		return entity.TotalCost{}, err
+	}
+
+	result := entity.TotalCost{Basis: entity.CostBasisCharges, From: filter.From, To: filter.To}
+	groups := map[string]int{}
+	for _, sub := range subs {
+		from := sub.StartDate
+		if filter.From != nil {
+			from = *filter.From
+		}
+
+		result.Total += addCost(groups, sub, sub.SplitBetween(from, *filter.To), filter.UserId, groupBy)
+	}
+
+	if groupBy != entity.GroupByNone {
+		result.Groups = sortedGroups(groups)
+	}
+
+	return result, nil
+}
+
+// getPriceTotal adds the prices of the matching subscriptions starting on or
+// after filter.From and ending by filter.To once, whatever the number of
+// months; a subscription without an end date does not end by filter.To.
+func (s *SubscriptionService) getPriceTotal(ctx context.Context, filter entity.SubscriptionFilter, groupBy entity.GroupBy) (entity.TotalCost, error) {
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return entity.TotalCost{}, invalidInput("from date should not be after to date")
+	}
+
+	if err := s.resolveFilter(ctx, &filter); err != nil {
+		return entity.TotalCost{}, err
+	}
+	filter.IncludeShared = true
+
+	subs, err := s.repo.GetSubscriptionsForPeriod(ctx, filter)
+	if err != nil {
+		return entity.TotalCost{}, err
+	}
+
+	result := entity.TotalCost{Basis: entity.CostBasisPrice, From: filter.From, To: filter.To}
+	groups := map[string]int{}
+	for _, sub := range subs {
+		if filter.From != nil && sub.StartDate.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && (sub.EndDate == nil || sub.EndDate.After(*filter.To)) {
+			continue
+		}
+
+		result.Total += addCost(groups, sub, sub.SplitPrice(), filter.UserId, groupBy)
+	}
+
+	if groupBy != entity.GroupByNone {
+		result.Groups = sortedGroups(groups)
+	}
+
+	return result, nil
+}
+
+// GetForecast projects what the matching subscriptions will charge in each
+// of the given number of months starting with the next one, as far as their
+// end dates, trials, promotions, pauses, billing periods and scheduled price
+// changes are known today.
+func (s *SubscriptionService) GetForecast(ctx context.Context, filter entity.SubscriptionFilter, months int, groupBy entity.GroupBy) (*entity.Forecast, error) {
+	if months <= 0 || months > maxForecastMonths {
+		return nil, invalidInput(fmt.Sprintf("months should be between 1 and %d", maxForecastMonths))
+	}
+
+	from := entity.CurrentYearMonth().AddMonths(1)
+	to := from.AddMonths(months - 1)
+	filter.From, filter.To = &from, &to
+
+	if err := s.resolveFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+	filter.IncludeShared = true
+
+	subs, err := s.repo.GetSubscriptionsForPeriod(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := &entity.Forecast{
+		From:   from,
+		To:     to,
+		Months: make([]entity.ForecastPoint, 0, months),
+	}
+	totals := map[string]int{}
+	for month := from; !month.After(to); month = month.AddMonths(1) {
+		point := entity.ForecastPoint{Month: month}
+		groups := map[string]int{}
+		for _, sub := range subs {
+			point.Total += addCost(groups, sub, sub.SplitIn(month), filter.UserId, groupBy)
+		}
+
+		forecast.Total += point.Total
+		point.Cumulative = forecast.Total
+		if groupBy != entity.GroupByNone {
+			point.Groups = sortedGroups(groups)
+			for key, total := range groups {
+				totals[key] += total
+			}
+		}
+
+		forecast.Months = append(forecast.Months, point)
+	}
+
+	if groupBy != entity.GroupByNone {
+		forecast.Groups = sortedGroups(totals)
+	}
+
+	return forecast, nil
+}
+
+// GetSettlement works out who owes whom for the shared subscriptions from
+// filter.From through filter.To: every sharing user owes the payer their
+// share. Without filter.To the period ends with the current month, and
+// without filter.From it is that month alone. With filter.UserId set, only
+// the debts of or to the user are returned.
+func (s *SubscriptionService) GetSettlement(ctx context.Context, filter entity.SubscriptionFilter) (*entity.Settlement, error) {
+	if filter.To == nil {
+		to := entity.CurrentYearMonth()
+		filter.To = &to
+	}
+	if filter.From == nil {
+		filter.From = filter.To
+	}
+
+	if filter.From.After(*filter.To) {
+		return nil, invalidInput("from date should not be after to date")
+	}
+
+	if err := s.resolveFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+	filter.IncludeShared = true
+	filter.Shared = true
+
+	subs, err := s.repo.GetSubscriptionsForPeriod(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	type pair struct{ from, to uuid.UUID }
+	owed := map[pair]int{}
+	for _, sub := range subs {
+		for userId, part := range sub.SplitBetween(*filter.From, *filter.To) {
+			if userId != sub.UserId {
+				owed[pair{userId, sub.UserId}] += part
+			}
+		}
+	}
+
+	settlement := &entity.Settlement{
+		From:  *filter.From,
+		To:    *filter.To,
+		Debts: []entity.Debt{},
+	}
+	for p, amount := range owed {
+		net := amount - owed[pair{p.to, p.from}]
+		if net <= 0 {
+			continue
+		}
+
+		if filter.UserId != uuid.Nil && p.from != filter.UserId && p.to != filter.UserId {
+			continue
+		}
+
+		settlement.Debts = append(settlement.Debts, entity.Debt{From: p.from, To: p.to, Amount: net})
+	}
+
+	sort.Slice(settlement.Debts, func(i, j int) bool {
+		a, b := settlement.Debts[i], settlement.Debts[j]
+		if a.Amount != b.Amount {
+			return a.Amount > b.Amount
+		}
+		if a.From != b.From {
+			return a.From.String() < b.From.String()
+		}
+		return a.To.String() < b.To.String()
+	})
+
+	return settlement, nil
+}
+
+// GetTrialConversions returns the trials converting to paid within the
+// given number of days from now, i.e. whose first paid month starts by then,
+// soonest first.
+func (s *SubscriptionService) GetTrialConversions(ctx context.Context, userId uuid.UUID, days int) ([]entity.TrialConversion, error) {
+	if days <= 0 || days > maxTrialDays {
+		return nil, invalidInput(fmt.Sprintf("days should be between 1 and %d", maxTrialDays))
+	}
+
+	now := time.Now()
+	first := entity.YearMonthOf(now).AddMonths(1)
+	last := entity.YearMonthOf(now.AddDate(0, 0, days))
+	if last.Before(first) {
+		return []entity.TrialConversion{}, nil
 	}
 
+	subs, err := s.repo.GetTrialsEndingBetween(ctx, userId, first.AddMonths(-1), last.AddMonths(-1))
+	if err != nil {
+		return nil, err
+	}
+
+	conversions := make([]entity.TrialConversion, 0, len(subs))
+	for _, sub := range subs {
+		convertsIn := sub.TrialEnd.AddMonths(1)
+		conversions = append(conversions, entity.TrialConversion{
+			Subscription: sub,
+			ConvertsIn:   convertsIn,
+			FirstCharge:  sub.PriceIn(convertsIn),
+		})
+	}
+
+	return conversions, nil
+}
+
+// prepare validates a subscription before it is written and brings it to
+// its stored form.
+func (s *SubscriptionService) prepare(ctx context.Context, e *entity.Subscription) error {
 	if e.Price < 0 {
-		return errors.New("price should be non-negative")
+		return invalidInput("price should be non-negative")
+	}
+
+	if err := isDateValid(e.StartDate, e.EndDate); err != nil {
+		return err
+	}
+
+	if err := validateOffer(*e); err != nil {
+		return err
+	}
+
+	if e.BillingPeriod == "" {
+		e.BillingPeriod = entity.BillingMonthly
+	}
+	if _, err := entity.BillingPeriodMonths(e.BillingPeriod); err != nil {
+		return invalidInput(err.Error())
+	}
+
+	if err := validatePriceChanges(e); err != nil {
+		return err
+	}
+
+	if len(e.Shares) == 0 {
+		e.SplitRule, e.Shares = nil, nil
+	} else if err := validateShares(*e); err != nil {
+		return err
+	}
+
+	if e.Category != nil {
+		category := normalizeLabel(*e.Category)
+		e.Category = &category
+		if category == "" {
+			e.Category = nil
+		}
 	}
 
-	err := isDateValid(&e.StartDate, e.EndDate)
+	tags, err := normalizeTags(e.Tags)
 	if err != nil {
 		return err
 	}
+	e.Tags = tags
+
+	return s.resolveService(ctx, e)
+}
+
+// resolveFilter matches a service name filter against the catalog, so that
+// filtering by an alias finds the subscriptions of the canonical service.
+func (s *SubscriptionService) resolveFilter(ctx context.Context, filter *entity.SubscriptionFilter) error {
+	filter.Category = normalizeLabel(filter.Category)
+	filter.Tag = normalizeLabel(filter.Tag)
+
+	if filter.ServiceName == "" || filter.ServiceId != nil {
+		return nil
+	}
 
-	err = s.repo.UpdateSubById(ctx, e)
+	svc, err := s.catalog.Resolve(ctx, filter.ServiceName)
 	if err != nil {
 		return err
 	}
+	if svc != nil {
+		filter.ServiceId = &svc.Id
+	}
 
 	return nil
 }
 
-func (s *SubscriptionService) GetTotalCost(ctx context.Context, userId uuid.UUID, serviceName string, fromDate string, toDate *string) (int, error) {
-	if fromDate != "" || toDate != nil {
-		err := isDateValid(&fromDate, toDate)
-		if err != nil {
-			return 0, err
+// resolveService links the subscription to the catalog entry its service
+// name matches and replaces the name with the canonical one. Names missing
+// from the catalog are kept as given.
+func (s *SubscriptionService) resolveService(ctx context.Context, e *entity.Subscription) error {
+	e.ServiceName = normalizeName(e.ServiceName)
+	e.ServiceId = nil
+	if e.ServiceName == "" {
+		return invalidInput("service name is required")
+	}
+
+	svc, err := s.catalog.Resolve(ctx, e.ServiceName)
+	if err != nil {
+		return err
+	}
+
+	if svc != nil {
+		e.ServiceId = &svc.Id
+		e.ServiceName = svc.Name
+		if e.Category == nil {
+			e.Category = svc.Category
+		}
+	}
+
+	return nil
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = normalizeLabel(tag)
+		if err := validateLabel("tag", tag); err != nil {
+			return nil, err
+		}
+
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
 		}
 	}
 
-	return s.repo.GetTotalCost(ctx, userId, serviceName, fromDate, toDate)
+	sort.Strings(normalized)
+	return normalized, nil
 }
 
-func isDateValid(sourceStartDateStr *string, sourceEndDateStr *string) error {
-	startDateStr, startDate, err := parseMMYYYYToFullDate(*sourceStartDateStr)
+// addSubscriptionEvent writes an event carrying the subscription as stored
+// by the transaction of repo, and returns the subscription.
+func addSubscriptionEvent(ctx context.Context, repo *repository.SubscriptionRepository, eventType string, id int) (*entity.Subscription, error) {
+	sub, err := repo.GetSubscriptionById(ctx, id)
 	if err != nil {
+		return nil, err
+	}
+
+	return sub, repo.AddEvent(ctx, eventType, sub)
+}
+
+// writeError turns the constraint violations a subscription write can hit
+// into input errors.
+func writeError(err error) error {
+	switch repository.ViolatedConstraint(err) {
+	case "subscription_user_id_fkey":
+		return invalidInput("user does not exist")
+	case "subscription_category_fkey":
+		return invalidInput("unknown category")
+	case "subscription_shares_user_id_fkey":
+		return invalidInput("share user does not exist")
+	default:
 		return err
 	}
-	*sourceStartDateStr = startDateStr
+}
+
+// addCost adds what a subscription charges, split between its users, to the
+// groups and returns the amount. With userId set, only that user's share
+// counts.
+func addCost(groups map[string]int, sub entity.Subscription, split map[uuid.UUID]int, userId uuid.UUID, groupBy entity.GroupBy) int {
+	if userId != uuid.Nil {
+		split = map[uuid.UUID]int{userId: split[userId]}
+	}
 
-	if sourceEndDateStr != nil {
-		endDateStr, endDate, err := parseMMYYYYToFullDate(*sourceEndDateStr)
-		if err != nil {
-			return err
+	var cost int
+	for id, part := range split {
+		cost += part
+		if groupBy == entity.GroupByUser {
+			groups[id.String()] += part
+		}
+	}
+
+	if groupBy != entity.GroupByUser {
+		for _, key := range groupBy.GroupKeys(sub) {
+			groups[key] += cost
+		}
+	}
+
+	return cost
+}
+
+func sortedGroups(totals map[string]int) []entity.CostGroup {
+	groups := make([]entity.CostGroup, 0, len(totals))
+	for key, total := range totals {
+		groups = append(groups, entity.CostGroup{Key: key, Total: total})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Total != groups[j].Total {
+			return groups[i].Total > groups[j].Total
+		}
+		return groups[i].Key < groups[j].Key
+	})
+
+	return groups
+}
+
+// validateOffer checks the trial and the promotional price of a
+// subscription.
+func validateOffer(e entity.Subscription) error {
+	if e.TrialEnd != nil {
+		if e.TrialEnd.Before(e.StartDate) {
+			return invalidInput("trial end should not be before start date")
 		}
-		*sourceEndDateStr = endDateStr
 
-		if !startDate.Before(endDate) {
-			return errors.New("start date should be before end date")
+		if e.EndDate != nil && e.TrialEnd.After(*e.EndDate) {
+			return invalidInput("trial end should not be after end date")
+		}
+	}
+
+	if (e.PromoPrice == nil) != (e.PromoMonths == nil) {
+		return invalidInput("promo price and promo months should be set together")
+	}
+
+	if e.PromoPrice != nil {
+		if *e.PromoPrice < 0 || *e.PromoPrice > e.Price {
+			return invalidInput("promo price should be between 0 and the price")
+		}
+
+		if *e.PromoMonths <= 0 {
+			return invalidInput("promo months should be positive")
 		}
 	}
 
 	return nil
 }
 
-func parseMMYYYYToFullDate(dateStr string) (string, time.Time, error) {
-	parts := strings.Split(dateStr, "-")
-	if len(parts) != 2 {
-		return "", time.Time{}, fmt.Errorf("неверный формат: ожидается MM-YYYY")
+// validatePriceChanges checks the scheduled price changes of a subscription
+// and sorts them, oldest first.
+func validatePriceChanges(e *entity.Subscription) error {
+	sort.Slice(e.PriceChanges, func(i, j int) bool {
+		return e.PriceChanges[i].From.Before(e.PriceChanges[j].From)
+	})
+
+	for i, c := range e.PriceChanges {
+		if c.Price < 0 {
+			return invalidInput("changed price should be non-negative")
+		}
+
+		if !c.From.After(e.StartDate) {
+			return invalidInput("price change should be after start date")
+		}
+
+		if e.EndDate != nil && c.From.After(*e.EndDate) {
+			return invalidInput("price change should not be after end date")
+		}
+
+		if i > 0 && c.From == e.PriceChanges[i-1].From {
+			return invalidInput("price changes should be in different months")
+		}
 	}
 
-	month, year := parts[0], parts[1]
+	return nil
+}
 
-	if len(month) != 2 || len(year) != 4 {
-		return "", time.Time{}, fmt.Errorf("неверная длина: месяц должен быть 2 цифры, год - 4 цифры")
+// validateShares checks the split rule and the shares of a shared
+// subscription.
+func validateShares(e entity.Subscription) error {
+	if e.SplitRule == nil {
+		return invalidInput("split rule is required with shares")
 	}
 
-	fullDateStr := fmt.Sprintf("%s-%s-01", year, month)
-	date, err := time.Parse("2006-01-02", fullDateStr)
-	if err != nil {
-		return "", time.Time{}, fmt.Errorf("неверная дата: %s", dateStr)
+	rule := *e.SplitRule
+	if rule != entity.SplitEqual && rule != entity.SplitPercentage && rule != entity.SplitFixed {
+		return invalidInput("split rule should be equal, percentage or fixed")
 	}
 
-	return fullDateStr, date, nil
+	seen := map[uuid.UUID]bool{}
+	var percents, amounts int
+	for _, share := range e.Shares {
+		if share.UserId == uuid.Nil {
+			return invalidInput("share user id is required")
+		}
+		if share.UserId == e.UserId {
+			return invalidInput("the paying user should not be listed in shares")
+		}
+		if seen[share.UserId] {
+			return invalidInput("user is listed in shares more than once")
+		}
+		seen[share.UserId] = true
+
+		switch rule {
+		case entity.SplitEqual:
+			if share.Percent != nil || share.Amount != nil {
+				return invalidInput("equal shares take neither percent nor amount")
+			}
+		case entity.SplitPercentage:
+			if share.Percent == nil || share.Amount != nil {
+				return invalidInput("percentage shares take a percent only")
+			}
+			if *share.Percent < 0 || *share.Percent > 100 {
+				return invalidInput("share percent should be between 0 and 100")
+			}
+			percents += *share.Percent
+		case entity.SplitFixed:
+			if share.Amount == nil || share.Percent != nil {
+				return invalidInput("fixed shares take an amount only")
+			}
+			if *share.Amount < 0 {
+				return invalidInput("share amount should be non-negative")
+			}
+			amounts += *share.Amount
+		}
+	}
+
+	if percents > 100 {
+		return invalidInput("share percents should add up to at most 100")
+	}
+
+	if amounts > e.Price {
+		return invalidInput("share amounts should add up to at most the price")
+	}
+
+	return nil
+}
+
+// validatePause checks that a new pause lies within the subscription and
+// does not overlap its other pauses.
+func validatePause(sub entity.Subscription, pause entity.Pause) error {
+	if pause.To != nil && pause.To.Before(pause.From) {
+		return invalidInput("pause end should not be before its start")
+	}
+
+	if pause.From.Before(sub.StartDate) {
+		return invalidInput("pause should not start before the subscription")
+	}
+
+	if sub.EndDate != nil {
+		if pause.From.After(*sub.EndDate) {
+			return invalidInput("pause should not start after the subscription ends")
+		}
+
+		if pause.To != nil && pause.To.After(*sub.EndDate) {
+			return invalidInput("pause should not end after the subscription")
+		}
+	}
+
+	for _, p := range sub.Pauses {
+		if p.Overlaps(pause.From, pause.To) {
+			return conflict("pause overlaps an existing pause")
+		}
+	}
+
+	return nil
+}
+
+// validateResume checks that an open pause can end before the given month:
+// from the current month on, so that billed months are kept, not before the
+// pause starts, so that its history is kept, and while the subscription
+// lasts. Resuming in the first month of the pause drops it.
+func validateResume(sub entity.Subscription, open entity.Pause, current, month entity.YearMonth) error {
+	if month.Before(current) {
+		return invalidInput("resume month should not be in the past")
+	}
+
+	if month.Before(open.From) {
+		return invalidInput("resume month should not be before the pause starts")
+	}
+
+	if sub.EndDate != nil && month.After(*sub.EndDate) {
+		return invalidInput("resume month should not be after the subscription ends")
+	}
+
+	return nil
+}
+
+// validateCancel checks that a subscription can end with the given month:
+// from the current month on, and not before it starts, so that a
+// subscription can be cancelled in its first month.
+func validateCancel(sub entity.Subscription, current, month entity.YearMonth) error {
+	if month.Before(current) {
+		return invalidInput("effective month should not be in the past")
+	}
+
+	if month.Before(sub.StartDate) {
+		return invalidInput("effective month should not be before the start date")
+	}
+
+	if sub.EndDate != nil && !sub.EndDate.After(month) {
+		return conflict("subscription already ends by the effective month")
+	}
+
+	return nil
+}
+
+func isDateValid(startDate entity.YearMonth, endDate *entity.YearMonth) error {
+	if startDate.IsZero() {
+		return invalidInput("start date is required")
+	}
+
+	if endDate != nil && endDate.Before(startDate) {
+		return invalidInput("end date should not be before start date")
+	}
+
+	return nil
 }
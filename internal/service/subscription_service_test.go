@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"test_task/internal/entity"
+	"testing"
+	"time"
+)
+
+func ym(year int, month time.Month) entity.YearMonth {
+	return entity.NewYearMonth(year, month)
+}
+
+func ymp(year int, month time.Month) *entity.YearMonth {
+	m := entity.NewYearMonth(year, month)
+	return &m
+}
+
+func TestValidateCancel(t *testing.T) {
+	current := ym(2025, time.June)
+
+	tests := []struct {
+		name  string
+		sub   entity.Subscription
+		month entity.YearMonth
+		want  error
+	}{
+		{
+			name:  "in the start month",
+			sub:   entity.Subscription{StartDate: current, BillingPeriod: entity.BillingMonthly},
+			month: current,
+		},
+		{
+			name:  "default month of a trial starting this month",
+			sub:   entity.Subscription{StartDate: current, TrialEnd: &current, BillingPeriod: entity.BillingMonthly},
+			month: entity.Subscription{StartDate: current, TrialEnd: &current}.PeriodEnd(current),
+		},
+		{
+			name:  "later month",
+			sub:   entity.Subscription{StartDate: ym(2025, time.January)},
+			month: ym(2025, time.August),
+		},
+		{
+			name:  "in the past",
+			sub:   entity.Subscription{StartDate: ym(2025, time.January)},
+			month: ym(2025, time.May),
+			want:  ErrInvalidInput,
+		},
+		{
+			name:  "before a future start",
+			sub:   entity.Subscription{StartDate: ym(2025, time.September)},
+			month: ym(2025, time.August),
+			want:  ErrInvalidInput,
+		},
+		{
+			name:  "already ending by then",
+			sub:   entity.Subscription{StartDate: ym(2025, time.January), EndDate: ymp(2025, time.July)},
+			month: ym(2025, time.August),
+			want:  ErrConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		err := validateCancel(tt.sub, current, tt.month)
+		if (tt.want == nil && err != nil) || (tt.want != nil && !errors.Is(err, tt.want)) {
+			t.Errorf("%s: validateCancel = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestValidateResume(t *testing.T) {
+	current := ym(2025, time.June)
+	sub := entity.Subscription{StartDate: ym(2025, time.January), EndDate: ymp(2025, time.October)}
+	open := entity.Pause{From: ym(2025, time.April)}
+
+	tests := []struct {
+		name  string
+		open  entity.Pause
+		month entity.YearMonth
+		want  bool
+	}{
+		{"this month", open, current, true},
+		{"later month", open, ym(2025, time.September), true},
+		{"first month of a future pause", entity.Pause{From: ym(2025, time.August)}, ym(2025, time.August), true},
+		{"in the past", open, ym(2025, time.May), false},
+		{"before the pause", entity.Pause{From: ym(2025, time.August)}, ym(2025, time.July), false},
+		{"after the end", open, ym(2025, time.November), false},
+	}
+
+	for _, tt := range tests {
+		err := validateResume(sub, tt.open, current, tt.month)
+		if tt.want != (err == nil) || (err != nil && !errors.Is(err, ErrInvalidInput)) {
+			t.Errorf("%s: validateResume = %v", tt.name, err)
+		}
+	}
+}
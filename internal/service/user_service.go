@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"net/mail"
+	"regexp"
+	"test_task/internal/entity"
+	"test_task/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var currencyRe = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type UserService struct {
+	repo *repository.UserRepository
+}
+
+func NewUserService(repo *repository.UserRepository) *UserService {
+	return &UserService{
+		repo: repo,
+	}
+}
+
+func (s *UserService) CreateUser(ctx context.Context, u entity.User) (*entity.User, error) {
+	if u.Id == uuid.Nil {
+		u.Id = uuid.New()
+	}
+
+	if u.Timezone == "" {
+		u.Timezone = "UTC"
+	}
+
+	if u.DefaultCurrency == "" {
+		u.DefaultCurrency = "RUB"
+	}
+
+	if u.Status == "" {
+		u.Status = entity.UserStatusActive
+	}
+
+	if err := validateUser(u); err != nil {
+		return nil, err
+	}
+
+	err := s.repo.CreateUser(ctx, u)
+	if repository.IsUniqueViolation(err) {
+		return nil, conflict("user with this id or email already exists")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+func (s *UserService) GetUserById(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	return s.repo.GetUserById(ctx, id)
+}
+
+func (s *UserService) GetAllUsers(ctx context.Context) ([]entity.User, error) {
+	return s.repo.GetAllUsers(ctx)
+}
+
+func (s *UserService) UpdateUser(ctx context.Context, u entity.User) error {
+	if err := validateUser(u); err != nil {
+		return err
+	}
+
+	err := s.repo.UpdateUser(ctx, u)
+	if repository.IsUniqueViolation(err) {
+		return conflict("user with this email already exists")
+	}
+
+	return err
+}
+
+// DeleteUser deletes a user with their budgets and preferences. A user who
+// still pays or shares subscriptions, or has statements, is kept; making them
+// inactive is the way to retire them.
+func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	err := s.repo.DeleteUser(ctx, id)
+	switch repository.ViolatedConstraint(err) {
+	case "subscription_user_id_fkey":
+		return conflict("user still has subscriptions")
+	case "subscription_shares_user_id_fkey":
+		return conflict("user still shares subscriptions of other users")
+	case "statements_user_id_fkey":
+		return conflict("user has statements, which are kept; set the status to inactive instead")
+	}
+
+	if repository.IsForeignKeyViolation(err) {
+		return conflict("user is still referred to")
+	}
+
+	return err
+}
+
+// IssueCalendarToken generates a new calendar token for a user, revoking
+// the previous one. Once a token was issued, replacing it takes the current
+// one, so that the feed of a user cannot be cut off or taken over by anyone
+// knowing their id. Only its hash is stored.
+func (s *UserService) IssueCalendarToken(ctx context.Context, id uuid.UUID, current string) (*entity.CalendarToken, error) {
+	token, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	var currentHash string
+	if current != "" {
+		currentHash = hashToken(current)
+	}
+
+	replaced, err := s.repo.ReplaceCalendarTokenHash(ctx, id, currentHash, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if !replaced {
+		return nil, forbidden("the current calendar token is required to replace it")
+	}
+
+	return &entity.CalendarToken{
+		Token: token,
+		URL:   "/users/" + id.String() + "/calendar.ics?token=" + token,
+	}, nil
+}
+
+// CheckCalendarToken returns sql.ErrNoRows unless the user exists and the
+// token is their calendar token, so that a wrong token does not reveal
+// whether the user exists.
+func (s *UserService) CheckCalendarToken(ctx context.Context, id uuid.UUID, token string) error {
+	hash, err := s.repo.GetCalendarTokenHash(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if hash == "" || subtle.ConstantTimeCompare([]byte(hash), []byte(hashToken(token))) != 1 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func validateUser(u entity.User) error {
+	if u.DisplayName == "" {
+		return invalidInput("display name is required")
+	}
+
+	// The email is used as is as the recipient of notifications, so forms
+	// with a display name or angle brackets are refused.
+	if u.Email != nil {
+		if addr, err := mail.ParseAddress(*u.Email); err != nil || addr.Address != *u.Email {
+			return invalidInput("invalid email: expected a bare address such as name@example.com")
+		}
+	}
+
+	if _, err := time.LoadLocation(u.Timezone); err != nil || u.Timezone == "" || u.Timezone == "Local" {
+		return invalidInput("unknown timezone")
+	}
+
+	if !currencyRe.MatchString(u.DefaultCurrency) {
+		return invalidInput("default currency should be a three-letter ISO 4217 code")
+	}
+
+	if u.Status != entity.UserStatusActive && u.Status != entity.UserStatusInactive {
+		return invalidInput("status should be active or inactive")
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"test_task/internal/entity"
+	"testing"
+)
+
+func TestValidateUserEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		valid bool
+	}{
+		{"anna@example.com", true},
+		{"anna.petrova+subs@mail.example.ru", true},
+		{"Anna <anna@example.com>", false},
+		{"<anna@example.com>", false},
+		{" anna@example.com", false},
+		{"anna", false},
+	}
+
+	for _, tt := range tests {
+		u := entity.User{
+			DisplayName:     "Anna",
+			Email:           &tt.email,
+			Timezone:        "UTC",
+			DefaultCurrency: "RUB",
+			Status:          entity.UserStatusActive,
+		}
+
+		if err := validateUser(u); (err == nil) != tt.valid {
+			t.Errorf("validateUser(email %q) = %v, want valid %v", tt.email, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"test_task/internal/entity"
+	"test_task/internal/netguard"
+	"test_task/internal/repository"
+	"time"
+)
+
+// Headers of webhook deliveries. The signature is "sha256=" followed by the
+// hex HMAC-SHA256, keyed with the webhook secret, of the timestamp, a dot and
+// the request body.
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	webhookBatchSize = 100
+	// webhookWorkers is how many deliveries are attempted at once, and
+	// webhookDispatchTime how long a run keeps starting attempts; the rest
+	// are left to the next run.
+	webhookWorkers         = 10
+	webhookDispatchTime    = 30 * time.Second
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+	maxRetryDelay          = 6 * time.Hour
+)
+
+type WebhookService struct {
+	repo        *repository.WebhookRepository
+	client      *http.Client
+	maxAttempts int
+	retryBase   time.Duration
+}
+
+// NewWebhookService creates a service giving up on a delivery after
+// maxAttempts attempts. The delay before a retry starts at retryBase and
+// doubles with every failed attempt.
+func NewWebhookService(repo *repository.WebhookRepository, maxAttempts int, retryBase time.Duration) *WebhookService {
+	return &WebhookService{
+		repo:        repo,
+		client:      netguard.NewClient(10 * time.Second),
+		maxAttempts: maxAttempts,
+		retryBase:   retryBase,
+	}
+}
+
+// CreateWebhook registers an active webhook. Without a secret one is
+// generated; the response is the only place the secret is shown.
+func (s *WebhookService) CreateWebhook(ctx context.Context, w entity.Webhook) (*entity.Webhook, error) {
+	if w.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		w.Secret = secret
+	}
+
+	w, err := normalizeWebhook(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	w.Active = true
+
+	return s.repo.CreateWebhook(ctx, w)
+}
+
+func (s *WebhookService) GetWebhookById(ctx context.Context, id int) (*entity.Webhook, error) {
+	if id <= 0 {
+		return nil, invalidInput("webhook id is required")
+	}
+
+	w, err := s.repo.GetWebhookById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	w.Secret = ""
+
+	return w, nil
+}
+
+func (s *WebhookService) GetAllWebhooks(ctx context.Context) ([]entity.Webhook, error) {
+	webhooks, err := s.repo.GetAllWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+// UpdateWebhook replaces a webhook, keeping its secret unless a new one is
+// given.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, w entity.Webhook) error {
+	if w.Id <= 0 {
+		return invalidInput("webhook id is required")
+	}
+
+	w, err := normalizeWebhook(ctx, w)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdateWebhook(ctx, w)
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	if id <= 0 {
+		return invalidInput("webhook id is required")
+	}
+
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// GetDeliveries returns the latest deliveries to a webhook, newest first.
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookId int, status string, limit int) ([]entity.WebhookDelivery, error) {
+	switch status {
+	case "", entity.DeliveryStatusPending, entity.DeliveryStatusSucceeded, entity.DeliveryStatusFailed:
+	default:
+		return nil, invalidInput("status should be pending, succeeded or failed")
+	}
+
+	if limit == 0 {
+		limit = defaultDeliveriesLimit
+	}
+	if limit < 0 || limit > maxDeliveriesLimit {
+		return nil, invalidInput(fmt.Sprintf("limit should be between 1 and %d", maxDeliveriesLimit))
+	}
+
+	if _, err := s.GetWebhookById(ctx, webhookId); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetDeliveries(ctx, webhookId, status, limit)
+}
+
+// Dispatch fans the outbox events out to the webhooks and makes the delivery
+// attempts that are due, for up to webhookDispatchTime. Failed attempts are
+// recorded and retried by a later run; only database errors are returned.
+func (s *WebhookService) Dispatch(ctx context.Context) error {
+	for {
+		n, err := s.repo.FanOutEvents(ctx, webhookBatchSize)
+		if err != nil {
+			return err
+		}
+		if n < webhookBatchSize {
+			break
+		}
+	}
+
+	deadline := time.Now().Add(webhookDispatchTime)
+	for time.Now().Before(deadline) {
+		pending, err := s.repo.GetDueDeliveries(ctx, webhookBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range s.attemptAll(ctx, pending, deadline) {
+			if err := s.repo.RecordAttempt(ctx, d); err != nil {
+				return err
+			}
+		}
+
+		if len(pending) < webhookBatchSize {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// attemptAll makes the attempts of a batch, webhookWorkers at a time, and
+// returns the deliveries updated with the outcomes. The attempts not started
+// by the deadline are skipped.
+func (s *WebhookService) attemptAll(ctx context.Context, pending []entity.PendingDelivery, deadline time.Time) []entity.WebhookDelivery {
+	done := make([]*entity.WebhookDelivery, len(pending))
+	sem := make(chan struct{}, webhookWorkers)
+	var wg sync.WaitGroup
+	for i, p := range pending {
+		sem <- struct{}{}
+		if !time.Now().Before(deadline) {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			d := s.attempt(ctx, p)
+			done[i] = &d
+		}()
+	}
+	wg.Wait()
+
+	var deliveries []entity.WebhookDelivery
+	for _, d := range done {
+		if d != nil {
+			deliveries = append(deliveries, *d)
+		}
+	}
+
+	return deliveries
+}
+
+// attempt posts the event to the webhook and returns the delivery updated
+// with the outcome.
+func (s *WebhookService) attempt(ctx context.Context, p entity.PendingDelivery) entity.WebhookDelivery {
+	d := p.Delivery
+	d.Attempts++
+
+	status, err := s.post(ctx, p)
+	d.ResponseStatus = nil
+	if status != 0 {
+		d.ResponseStatus = &status
+	}
+
+	if err == nil {
+		now := time.Now()
+		d.Status = entity.DeliveryStatusSucceeded
+		d.LastError = nil
+		d.DeliveredAt = &now
+		return d
+	}
+
+	msg := err.Error()
+	d.LastError = &msg
+
+	if d.Attempts >= s.maxAttempts {
+		d.Status = entity.DeliveryStatusFailed
+		return d
+	}
+
+	next := time.Now().Add(s.retryDelay(d.Attempts))
+	d.NextAttemptAt = &next
+
+	return d
+}
+
+func (s *WebhookService) post(ctx context.Context, p entity.PendingDelivery) (int, error) {
+	body, err := json.Marshal(p.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, p.Event.Type)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(p.Delivery.Id, 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(p.Webhook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook answered %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// retryDelay is the delay after the given number of failed attempts.
+func (s *WebhookService) retryDelay(attempts int) time.Duration {
+	delay := s.retryBase
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxRetryDelay)
+}
+
+// SignWebhookPayload returns the signature header value of a delivery.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func normalizeWebhook(ctx context.Context, w entity.Webhook) (entity.Webhook, error) {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return w, invalidInput("url should be an http or https URL")
+	}
+
+	if err := netguard.CheckHost(ctx, u.Hostname()); err != nil {
+		return w, invalidInput("url " + err.Error())
+	}
+
+	if w.Secret != "" && len(w.Secret) < 16 {
+		return w, invalidInput("secret should be at least 16 characters long")
+	}
+
+	if len(w.EventTypes) == 0 {
+		w.EventTypes = slices.Clone(entity.EventTypes)
+		return w, nil
+	}
+
+	var types []string
+	for _, t := range w.EventTypes {
+		if !slices.Contains(entity.EventTypes, t) {
+			return w, invalidInput("unknown event type " + t)
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	w.EventTypes = types
+
+	return w, nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate secret: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -1 +1,5 @@
-DROP TABLE subscription;
\ No newline at end of file
+DROP TRIGGER IF EXISTS trigger_update_dates ON subscription;
+
+DROP FUNCTION IF EXISTS update_formatted_dates();
+
+DROP TABLE IF EXISTS subscription;
@@ -0,0 +1,24 @@
+ALTER TABLE subscription
+    ADD COLUMN formatted_start_date TEXT,
+    ADD COLUMN formatted_end_date TEXT;
+
+UPDATE subscription
+SET formatted_start_date = to_char(start_date, 'MM-YYYY'),
+    formatted_end_date = to_char(end_date, 'MM-YYYY');
+
+CREATE OR REPLACE FUNCTION update_formatted_dates()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.formatted_start_date := to_char(NEW.start_date, 'MM-YYYY');
+    NEW.formatted_end_date := CASE 
+        WHEN NEW.end_date IS NOT NULL THEN to_char(NEW.end_date, 'MM-YYYY')
+        ELSE NULL 
+    END;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_update_dates
+    BEFORE INSERT OR UPDATE ON subscription
+    FOR EACH ROW
+    EXECUTE FUNCTION update_formatted_dates();
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS trigger_update_dates ON subscription;
+
+DROP FUNCTION IF EXISTS update_formatted_dates();
+
+ALTER TABLE subscription
+    DROP COLUMN formatted_start_date,
+    DROP COLUMN formatted_end_date;
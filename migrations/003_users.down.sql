@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS subscription_user_id_idx;
+
+ALTER TABLE subscription
+    DROP CONSTRAINT IF EXISTS subscription_user_id_fkey;
+
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,23 @@
+CREATE TABLE users(
+    id UUID PRIMARY KEY,
+    display_name VARCHAR(256) NOT NULL,
+    email VARCHAR(320),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    default_currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT users_status_check CHECK (status IN ('active', 'inactive'))
+);
+
+CREATE UNIQUE INDEX users_email_key ON users (lower(email));
+
+-- Existing subscriptions keep working: every user id already in use gets a
+-- placeholder user named after the id.
+INSERT INTO users(id, display_name)
+SELECT DISTINCT user_id, user_id::text
+FROM subscription;
+
+ALTER TABLE subscription
+    ADD CONSTRAINT subscription_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+
+CREATE INDEX subscription_user_id_idx ON subscription(user_id);
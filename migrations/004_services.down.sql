@@ -0,0 +1,4 @@
+ALTER TABLE subscription
+    DROP COLUMN IF EXISTS service_id;
+
+DROP TABLE IF EXISTS services;
@@ -0,0 +1,16 @@
+CREATE TABLE services(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    category VARCHAR(64),
+    vendor_url TEXT,
+    default_price INT,
+    CONSTRAINT services_default_price_check CHECK (default_price >= 0)
+);
+
+CREATE UNIQUE INDEX services_name_key ON services (lower(name));
+
+ALTER TABLE subscription
+    ADD COLUMN service_id INT REFERENCES services(id) ON DELETE SET NULL;
+
+CREATE INDEX subscription_service_id_idx ON subscription(service_id);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS subscription_tags;
+
+ALTER TABLE subscription
+    DROP COLUMN IF EXISTS category;
+
+ALTER TABLE services
+    DROP CONSTRAINT IF EXISTS services_category_fkey;
+
+DROP TABLE IF EXISTS categories;
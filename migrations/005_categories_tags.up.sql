@@ -0,0 +1,39 @@
+CREATE TABLE categories(
+    name VARCHAR(64) PRIMARY KEY
+);
+
+INSERT INTO categories(name)
+VALUES ('streaming'), ('music'), ('cloud'), ('productivity'), ('gaming'), ('news'), ('education'), ('other');
+
+UPDATE services
+SET category = lower(trim(category))
+WHERE category IS NOT NULL;
+
+INSERT INTO categories(name)
+SELECT DISTINCT category
+FROM services
+WHERE category IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE services
+    ADD CONSTRAINT services_category_fkey FOREIGN KEY (category) REFERENCES categories(name);
+
+ALTER TABLE subscription
+    ADD COLUMN category VARCHAR(64),
+    ADD CONSTRAINT subscription_category_fkey FOREIGN KEY (category) REFERENCES categories(name);
+
+CREATE INDEX subscription_category_idx ON subscription(category);
+
+-- Subscriptions already linked to the catalog take their service's category.
+UPDATE subscription
+SET category = services.category
+FROM services
+WHERE subscription.service_id = services.id;
+
+CREATE TABLE subscription_tags(
+    subscription_id INT NOT NULL REFERENCES subscription(id) ON DELETE CASCADE,
+    tag VARCHAR(64) NOT NULL,
+    PRIMARY KEY (subscription_id, tag)
+);
+
+CREATE INDEX subscription_tags_tag_idx ON subscription_tags(tag);
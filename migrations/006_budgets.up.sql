@@ -0,0 +1,26 @@
+CREATE TABLE budgets(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    user_id UUID,
+    category VARCHAR(64),
+    service_name VARCHAR(256),
+    period VARCHAR(16) NOT NULL,
+    amount INT NOT NULL,
+    CONSTRAINT budgets_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT budgets_category_fkey FOREIGN KEY (category) REFERENCES categories(name) ON DELETE CASCADE,
+    CONSTRAINT budgets_period_check CHECK (period IN ('monthly', 'yearly')),
+    CONSTRAINT budgets_amount_check CHECK (amount > 0)
+);
+
+CREATE INDEX budgets_user_id_idx ON budgets(user_id);
+
+-- One row per budget, budget period and crossed threshold, so each alert is
+-- raised once even when the status is computed concurrently.
+CREATE TABLE budget_alerts(
+    budget_id INT NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    threshold INT NOT NULL,
+    spent INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (budget_id, period_start, threshold)
+);
@@ -0,0 +1,22 @@
+-- Users without a row get the server defaults.
+CREATE TABLE notification_preferences(
+    user_id UUID PRIMARY KEY,
+    channel VARCHAR(16) NOT NULL,
+    webhook_url TEXT,
+    renewal_reminders BOOLEAN NOT NULL DEFAULT TRUE,
+    budget_alerts BOOLEAN NOT NULL DEFAULT TRUE,
+    reminder_days INT,
+    CONSTRAINT notification_preferences_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT notification_preferences_channel_check CHECK (channel IN ('log', 'email', 'webhook')),
+    CONSTRAINT notification_preferences_reminder_days_check CHECK (reminder_days > 0)
+);
+
+-- One row per reminder claimed for sending, so that each reminder goes out
+-- once however many server replicas run the scheduler.
+CREATE TABLE sent_reminders(
+    subscription_id INT NOT NULL REFERENCES subscription(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    month DATE NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, kind, month)
+);
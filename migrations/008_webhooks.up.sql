@@ -0,0 +1,37 @@
+-- Transactional outbox: events are written in the transaction of the change
+-- they describe and dispatched afterwards.
+CREATE TABLE outbox_events(
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events(id) WHERE dispatched_at IS NULL;
+
+CREATE TABLE webhooks(
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE webhook_deliveries(
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    response_status INT,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT webhook_deliveries_status_check CHECK (status IN ('pending', 'succeeded', 'failed')),
+    CONSTRAINT webhook_deliveries_event_key UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
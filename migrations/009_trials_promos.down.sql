@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS subscription_trial_end_idx;
+
+ALTER TABLE subscription
+    DROP CONSTRAINT IF EXISTS subscription_promo_check,
+    DROP COLUMN IF EXISTS promo_months,
+    DROP COLUMN IF EXISTS promo_price,
+    DROP COLUMN IF EXISTS trial_end;
@@ -0,0 +1,10 @@
+ALTER TABLE subscription
+    ADD COLUMN trial_end DATE,
+    ADD COLUMN promo_price INT,
+    ADD COLUMN promo_months INT,
+    ADD CONSTRAINT subscription_promo_check CHECK (
+        (promo_price IS NULL AND promo_months IS NULL)
+        OR (promo_price >= 0 AND promo_months > 0)
+    );
+
+CREATE INDEX subscription_trial_end_idx ON subscription(trial_end) WHERE trial_end IS NOT NULL;
@@ -0,0 +1,13 @@
+-- A pause stops billing from start_month through end_month inclusive, or
+-- until the subscription is resumed when end_month is NULL. Overlaps are
+-- rejected by the service, which locks the subscription row while checking.
+CREATE TABLE subscription_pauses(
+    id SERIAL PRIMARY KEY,
+    subscription_id INT NOT NULL REFERENCES subscription(id) ON DELETE CASCADE,
+    start_month DATE NOT NULL,
+    end_month DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT subscription_pauses_months_check CHECK (end_month IS NULL OR end_month >= start_month)
+);
+
+CREATE INDEX subscription_pauses_subscription_id_idx ON subscription_pauses(subscription_id);
@@ -0,0 +1,19 @@
+-- Cancellations keep a snapshot of the subscription so that the churn report
+-- still counts them after the subscription is deleted.
+CREATE TABLE subscription_cancellations(
+    id SERIAL PRIMARY KEY,
+    subscription_id INT UNIQUE REFERENCES subscription(id) ON DELETE SET NULL,
+    user_id UUID NOT NULL,
+    service_name VARCHAR(256) NOT NULL,
+    service_id INT,
+    price INT NOT NULL,
+    end_month DATE NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    comment TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT subscription_cancellations_reason_check CHECK (
+        reason IN ('too_expensive', 'not_using', 'switched_service', 'technical_issues', 'temporary', 'other')
+    )
+);
+
+CREATE INDEX subscription_cancellations_end_month_idx ON subscription_cancellations(end_month);
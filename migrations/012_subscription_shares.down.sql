@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS subscription_shares;
+
+ALTER TABLE subscription
+    DROP CONSTRAINT IF EXISTS subscription_split_rule_check,
+    DROP COLUMN IF EXISTS split_rule;
@@ -0,0 +1,17 @@
+-- A shared subscription is paid by its user and split with the users in
+-- subscription_shares according to split_rule; the payer keeps the rest.
+ALTER TABLE subscription
+    ADD COLUMN split_rule VARCHAR(16),
+    ADD CONSTRAINT subscription_split_rule_check CHECK (split_rule IN ('equal', 'percentage', 'fixed'));
+
+CREATE TABLE subscription_shares(
+    subscription_id INT NOT NULL REFERENCES subscription(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id),
+    percent INT,
+    amount INT,
+    PRIMARY KEY (subscription_id, user_id),
+    CONSTRAINT subscription_shares_percent_check CHECK (percent BETWEEN 0 AND 100),
+    CONSTRAINT subscription_shares_amount_check CHECK (amount >= 0)
+);
+
+CREATE INDEX subscription_shares_user_id_idx ON subscription_shares(user_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS subscription_price_changes;
+
+ALTER TABLE subscription
+    DROP CONSTRAINT IF EXISTS subscription_billing_period_check,
+    DROP COLUMN IF EXISTS billing_period;
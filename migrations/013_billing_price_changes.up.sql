@@ -0,0 +1,13 @@
+-- Subscriptions are charged once per billing period, starting with their
+-- first paid month. Price changes take effect from effective_month on.
+ALTER TABLE subscription
+    ADD COLUMN billing_period VARCHAR(16) NOT NULL DEFAULT 'monthly',
+    ADD CONSTRAINT subscription_billing_period_check CHECK (billing_period IN ('monthly', 'quarterly', 'yearly'));
+
+CREATE TABLE subscription_price_changes(
+    subscription_id INT NOT NULL REFERENCES subscription(id) ON DELETE CASCADE,
+    effective_month DATE NOT NULL,
+    price INT NOT NULL,
+    PRIMARY KEY (subscription_id, effective_month),
+    CONSTRAINT subscription_price_changes_price_check CHECK (price >= 0)
+);
@@ -0,0 +1,4 @@
+-- Only the SHA-256 of a calendar token is kept; the token itself is shown
+-- once, when it is issued.
+ALTER TABLE users
+    ADD COLUMN calendar_token_hash CHAR(64);
@@ -0,0 +1,19 @@
+-- A statement is stored as generated, so that editing subscriptions or
+-- budgets later does not change what was already reported.
+CREATE TABLE statements(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month DATE NOT NULL,
+    content JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, month)
+);
+
+CREATE FUNCTION statements_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'statements cannot be changed';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER statements_immutable
+    BEFORE UPDATE ON statements
+    FOR EACH ROW EXECUTE FUNCTION statements_immutable();
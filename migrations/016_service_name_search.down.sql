@@ -0,0 +1,2 @@
+-- The extension is kept: other objects may depend on it.
+DROP INDEX IF EXISTS subscription_service_name_trgm_idx;
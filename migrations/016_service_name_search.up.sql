@@ -0,0 +1,5 @@
+-- Trigram index for fuzzy and partial search over service names: it serves
+-- the %, <% and ILIKE operators.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX subscription_service_name_trgm_idx ON subscription USING gin (service_name gin_trgm_ops);
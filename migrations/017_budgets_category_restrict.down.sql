@@ -0,0 +1,3 @@
+ALTER TABLE budgets
+    DROP CONSTRAINT budgets_category_fkey,
+    ADD CONSTRAINT budgets_category_fkey FOREIGN KEY (category) REFERENCES categories(name) ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+-- A category still used by budgets cannot be deleted, as for services and
+-- subscriptions, instead of taking the budgets and their alerts with it.
+ALTER TABLE budgets
+    DROP CONSTRAINT budgets_category_fkey,
+    ADD CONSTRAINT budgets_category_fkey FOREIGN KEY (category) REFERENCES categories(name) ON DELETE RESTRICT;
@@ -0,0 +1,15 @@
+-- The highest event id pruned from the outbox, so that a client resuming the
+-- event stream can tell whether events after its last one were dropped: the
+-- ids in the log have gaps, and an empty log says nothing about what it held.
+CREATE TABLE outbox_pruned(
+    singleton BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (singleton),
+    pruned_through BIGINT NOT NULL DEFAULT 0
+);
+
+-- Whatever came before the oldest event left, or before the next id when the
+-- log is empty, may have been pruned already.
+INSERT INTO outbox_pruned(pruned_through)
+SELECT COALESCE(
+    (SELECT MIN(id) - 1 FROM outbox_events),
+    (SELECT CASE WHEN is_called THEN last_value ELSE 0 END FROM outbox_events_id_seq)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE statements
+    DROP CONSTRAINT statements_user_id_fkey,
+    ADD CONSTRAINT statements_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
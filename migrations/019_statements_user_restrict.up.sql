@@ -0,0 +1,5 @@
+-- Statements are kept as issued, so a user with statements cannot be deleted,
+-- as for subscriptions; such a user is made inactive instead.
+ALTER TABLE statements
+    DROP CONSTRAINT statements_user_id_fkey,
+    ADD CONSTRAINT statements_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT;
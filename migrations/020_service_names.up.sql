@@ -0,0 +1,15 @@
+-- Every name and alias of a catalog entry, lowercased, so that two entries
+-- cannot claim the same name even when created concurrently.
+CREATE TABLE service_names(
+    name_key TEXT PRIMARY KEY,
+    service_id INT NOT NULL REFERENCES services(id) ON DELETE CASCADE
+);
+
+CREATE INDEX service_names_service_id_idx ON service_names(service_id);
+
+-- A name claimed twice already stays with the entry it resolves to, the one
+-- with that canonical name or else the oldest one.
+INSERT INTO service_names(name_key, service_id)
+SELECT DISTINCT ON (lower(n.name)) lower(n.name), s.id
+FROM services s, unnest(array_prepend(s.name::text, s.aliases)) AS n(name)
+ORDER BY lower(n.name), lower(s.name) = lower(n.name) DESC, s.id;